@@ -5,11 +5,17 @@ import (
 	"explorer/internal/adapters/distribute"
 	apiHttp "explorer/internal/adapters/http"
 	mbta "explorer/internal/adapters/mbta/stream"
+	"explorer/internal/adapters/store"
 	"explorer/internal/core/usecases"
 	"explorer/internal/infrastructure/config"
 	"explorer/internal/infrastructure/middleware"
+	ports "explorer/internal/ports/streaming"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
@@ -30,18 +36,76 @@ func main() {
 	cache := config.MemcachedConfig()
 
 	// Initialize the use case layer by creating an mbtaApiHelper instance with the MBTA client
-	mbtaApiHelper := usecases.NewMbtaApiHelper(data.NewMBTAClient(key), cache)
+	mbtaApiHelper := usecases.NewMbtaApiHelper(data.NewMBTAClient(key, config.GetAPIBaseURL()), cache)
 
 	// Initialize a new Gorilla Mux router
 	r := mux.NewRouter()
 
-	// Initialize the stream manager with the MBTA stream source and client distributor
-	distributor := distribute.NewClientDistributor()
-	source := mbta.NewMBTAStreamSource(distributor)
-	sm := usecases.NewStreamManagerUseCase(source, distributor)
+	// Configure how the client distributors handle slow clients
+	distributorOptions := distribute.DefaultDistributorOptions()
+	distributorOptions.Policy, err = distribute.ParseSlowConsumerPolicy(config.GetSlowConsumerPolicy())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if maxDrops := config.GetSlowConsumerMaxDrops(); maxDrops > 0 {
+		distributorOptions.MaxDrops = maxDrops
+	}
+
+	// Configure how the stream sources reconnect to the MBTA API
+	sourceOptions := mbta.DefaultSourceOptions()
+	sourceOptions.RouteTypes = mbtaApiHelper // Tag streamed vehicles with their route type and mode
+	sourceOptions.Alerts = mbtaApiHelper     // Resolve the routes and stops affected by streamed alerts
+	if idleTimeout := config.GetStreamIdleTimeout(); idleTimeout > 0 {
+		sourceOptions.IdleTimeout = idleTimeout
+	}
+
+	// Record every raw upstream event, if enabled, so it can be replayed later
+	if path := config.GetStreamRecordFile(); path != "" {
+		sourceOptions.Recorder, err = mbta.NewFileRecorder(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Recording upstream stream events to %s", path)
+	}
+
+	// Play back a recording instead of connecting to the MBTA API, if configured
+	replayFile := config.GetStreamReplayFile()
+	replaySpeed, err := mbta.ParseReplaySpeed(config.GetStreamReplaySpeed())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if replayFile != "" {
+		log.Printf("Replaying upstream stream events from %s", replayFile)
+	}
+
+	// Initialize the stream registry, creating a stream source, client distributor
+	// and vehicle store for each distinct upstream stream
+	registry := usecases.NewStreamRegistryUseCase(func() (ports.StreamSource, ports.StreamDistributor, ports.StreamStore) {
+		distributor := distribute.NewClientDistributor(distributorOptions)
+		streamStore := store.NewStreamStore()
+		if replayFile != "" {
+			return mbta.NewReplayStreamSource(distributor, streamStore, mbtaApiHelper, mbtaApiHelper, replayFile, replaySpeed, config.GetStreamReplayLoop()), distributor, streamStore
+		}
+		return mbta.NewMBTAStreamSource(distributor, streamStore, sourceOptions), distributor, streamStore
+	}, config.GetStreamGracePeriod())
+
+	// Stop the upstream streams and exit on system shutdown signals (e.g., SIGINT, SIGTERM)
+	go func() {
+		// Create a channel to receive shutdown signals
+		sigChan := make(chan os.Signal, 1)
+		// Notify the channel for interrupt (Ctrl+C) or termination signals
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+		// Wait for a signal to shut down the stream
+		<-sigChan
+		registry.Shutdown()
+		// Allow a brief moment for goroutines to clean up
+		time.Sleep(1 * time.Second)
+		// Exit the program
+		os.Exit(0)
+	}()
 
 	// Register the routes with the router
-	apiHttp.RegisterRoutes(r, mbtaApiHelper, sm)
+	apiHttp.RegisterRoutes(r, mbtaApiHelper, registry)
 
 	// Configure CORS
 	corsHandler := middleware.SetCorsHandler(r)
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"explorer/internal/adapters/mbta/fake"
+	"flag"
+	"log"
+	"net/http"
+	"time"
+)
+
+// fakembta runs the fake MBTA V3 API so the explorer API can be run locally without an
+// MBTA API key or network access. Point the explorer at it with MBTA_API_BASE_URL.
+func main() {
+	addr := flag.String("addr", ":8081", "Address to listen on")
+	interval := flag.Duration("interval", 2*time.Second, "Time between streamed vehicle updates")
+	flag.Parse()
+
+	server, err := fake.NewServer(*interval)
+	if err != nil {
+		log.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	log.Printf("Fake MBTA API listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
@@ -4,10 +4,15 @@ go 1.23.3
 
 require github.com/gorilla/mux v1.8.1
 
-require github.com/joho/godotenv v1.5.1
+require (
+	github.com/bradfitz/gomemcache v0.0.0-20230905024940-24af94b03874
+	github.com/joho/godotenv v1.5.1
+	github.com/rs/cors v1.11.1
+	github.com/twpayne/go-polyline v1.1.1
+)
 
 require (
-	github.com/bradfitz/gomemcache v0.0.0-20230905024940-24af94b03874 // indirect
-	github.com/rs/cors v1.11.1 // indirect
-	github.com/twpayne/go-polyline v1.1.1 // indirect
+	github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs v1.0.0
+	github.com/gorilla/websocket v1.5.3
+	google.golang.org/protobuf v1.26.0
 )
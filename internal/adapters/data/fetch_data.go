@@ -1,6 +1,8 @@
 package data
 
 import (
+	"context"
+	"explorer/internal/ports/data"
 	"fmt"
 	"io"
 	"log"
@@ -9,16 +11,19 @@ import (
 
 // fetchData is a helper method that makes a GET request to the given endpoint
 // It returns the raw response body as a byte slice or an error if something goes wrong
-func (m *mbtaClientImpl) fetchData(endpoint string) ([]byte, error) {
-	// Create a new GET request with the given endpoint
-	req, err := http.NewRequest("GET", endpoint, nil)
+// The request is abandoned, failing with the context's error, as soon as ctx is done
+func (m *mbtaClientImpl) fetchData(ctx context.Context, endpoint string) ([]byte, error) {
+	// Create a new GET request with the given endpoint, bound to the caller's context
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		log.Println("Error building the request") // Log if there's an error creating the request
 		return nil, err
 	}
 
-	// Set the API key in the request header for authentication
-	req.Header.Set("x-api-key", m.apiKey)
+	// Set the API key in the request header for authentication, if there is one (e.g. not for a fake server)
+	if m.apiKey != "" {
+		req.Header.Set("x-api-key", m.apiKey)
+	}
 
 	// Execute the request using the HTTP client
 	resp, err := m.client.Do(req)
@@ -28,6 +33,11 @@ func (m *mbtaClientImpl) fetchData(endpoint string) ([]byte, error) {
 	}
 	defer resp.Body.Close() // Ensure the response body is closed after use
 
+	// Let callers tell a missing resource apart from a failure
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("error fetching data: %v: %w", resp.Status, data.ErrNotFound)
+	}
+
 	// Check if the response status code is not OK (200)
 	if resp.StatusCode != http.StatusOK {
 		log.Println("Status code not OK") // Log if the status code is not OK
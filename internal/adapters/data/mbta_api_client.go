@@ -1,6 +1,7 @@
 package data
 
 import (
+	"context"
 	"encoding/json"
 	"explorer/internal/core/domain/models"
 	"explorer/internal/pkg"
@@ -8,33 +9,38 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
-const mbtaAPIBaseUrl = "https://api-v3.mbta.com"
-
 // mbtaClientImpl is the implementation of the MBTAClient interface
-// It holds the API key and HTTP client used for making requests
+// It holds the API key, base URL and HTTP client used for making requests
 type mbtaClientImpl struct {
-	apiKey string
-	client *http.Client
+	apiKey  string
+	baseURL string
+	client  *http.Client
 }
 
 // NewMBTAClient is a constructor function that initializes and returns a new instance of mbtaClientImpl
-func NewMBTAClient(apiKey string) data.MBTAClient {
+// The baseURL is normally the production MBTA V3 API, but can point at a fake server for testing
+func NewMBTAClient(apiKey, baseURL string) data.MBTAClient {
 	return &mbtaClientImpl{
-		apiKey: apiKey,                                  // Set the API key from the argument
-		client: &http.Client{Timeout: 10 * time.Second}, // Set a timeout of 10 seconds for HTTP requests
+		apiKey:  apiKey,                                  // Set the API key from the argument
+		baseURL: baseURL,                                 // Set the API base URL from the argument
+		client:  &http.Client{Timeout: 10 * time.Second}, // Set a timeout of 10 seconds for HTTP requests
 	}
 }
 
 // FetchShapes fetches the shape data for a given route ID from the MBTA API
-func (m *mbtaClientImpl) FetchShapes(routeID string) (models.DecodedRouteShape, error) {
+func (m *mbtaClientImpl) FetchShapes(ctx context.Context, routeID string) (models.DecodedRouteShape, error) {
 	// Format the endpoint URL to include the route ID in the query parameters
-	endpoint := fmt.Sprintf("%s/shapes?filter[route]=%s", mbtaAPIBaseUrl, routeID)
+	endpoint := fmt.Sprintf("%s/shapes?%s", m.baseURL, url.Values{"filter[route]": {routeID}}.Encode())
 
 	// Call fetchData to get the raw data from the API
-	data, err := m.fetchData(endpoint)
+	data, err := m.fetchData(ctx, endpoint)
 	if err != nil {
 		return models.DecodedRouteShape{}, fmt.Errorf("failed to fetch shapes: %w", err)
 	}
@@ -44,7 +50,7 @@ func (m *mbtaClientImpl) FetchShapes(routeID string) (models.DecodedRouteShape,
 
 	// Unmarshal the raw JSON data into the shapeResponse variable
 	if err := json.Unmarshal(data, &shapeResponse); err != nil {
-		log.Fatal(err) // Log a fatal error if unmarshalling fails (this will stop execution)
+		return models.DecodedRouteShape{}, fmt.Errorf("error unmarshaling shapes: %w", err)
 	}
 
 	// Decode the shape data into coordinates
@@ -60,12 +66,12 @@ func (m *mbtaClientImpl) FetchShapes(routeID string) (models.DecodedRouteShape,
 }
 
 // FetchStops fetches the list of stops for a given route ID from the MBTA API
-func (m *mbtaClientImpl) FetchStops(routeID string) ([]models.Stop, error) {
+func (m *mbtaClientImpl) FetchStops(ctx context.Context, routeID string) ([]models.Stop, error) {
 	// Format the endpoint URL to include the route ID in the query parameters
-	endpoint := fmt.Sprintf("%s/stops?filter[route]=%s", mbtaAPIBaseUrl, routeID)
+	endpoint := fmt.Sprintf("%s/stops?%s", m.baseURL, url.Values{"filter[route]": {routeID}}.Encode())
 
 	// Call fetchData to get the raw data from the API
-	data, err := m.fetchData(endpoint)
+	data, err := m.fetchData(ctx, endpoint)
 	if err != nil {
 		return nil, err // Return the error if fetching the data fails
 	}
@@ -75,19 +81,108 @@ func (m *mbtaClientImpl) FetchStops(routeID string) ([]models.Stop, error) {
 
 	// Unmarshal the raw JSON data into the stopsResponse variable
 	if err := json.Unmarshal(data, &stopsResponse); err != nil {
-		log.Fatal(err) // Log a fatal error if unmarshalling fails (this will stop execution)
+		return nil, fmt.Errorf("error unmarshaling stops: %w", err)
 	}
 
 	// Return the list of stops from the response data
 	return stopsResponse.Data, nil
 }
 
-// FetchLiveData fetches the live vehicle data for a given route ID from the MBTA API
-func (m *mbtaClientImpl) FetchLiveData(routeID string) ([]models.Vehicle, error) {
-	endpoint := fmt.Sprintf("%s/vehicles?filter[route]=%s", mbtaAPIBaseUrl, routeID)
+// FetchStopsByID fetches the stops with the given IDs from the MBTA API, in one request
+func (m *mbtaClientImpl) FetchStopsByID(ctx context.Context, stopIDs []string) ([]models.Stop, error) {
+	endpoint := fmt.Sprintf("%s/stops?%s", m.baseURL, url.Values{"filter[id]": {strings.Join(stopIDs, ",")}}.Encode())
+
+	data, err := m.fetchData(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch stops: %w", err)
+	}
+
+	var stopsResponse models.StopsResponse
+	if err := json.Unmarshal(data, &stopsResponse); err != nil {
+		return nil, fmt.Errorf("error unmarshaling stops: %w", err)
+	}
+
+	return stopsResponse.Data, nil
+}
+
+// FetchRoutes fetches the routes of the given route types from the MBTA API, or every route if routeTypes is empty.
+// The lines of the routes are requested in the same call with include=line, and the routes are sorted as the MBTA presents them.
+func (m *mbtaClientImpl) FetchRoutes(ctx context.Context, routeTypes []int) ([]models.Route, error) {
+	query := url.Values{"include": {"line"}}
+	if len(routeTypes) > 0 {
+		query.Set("filter[type]", joinInts(routeTypes))
+	}
+	endpoint := fmt.Sprintf("%s/routes?%s", m.baseURL, query.Encode())
+
+	data, err := m.fetchData(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch routes: %w", err)
+	}
+
+	var response models.RoutesResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshaling routes: %w", err)
+	}
+
+	// Index the included lines so each route can be linked to its line
+	lines := make(map[string]*models.Line, len(response.Included))
+	for _, resource := range response.Included {
+		lines[resource.ID] = &models.Line{
+			ID:        resource.ID,
+			LongName:  resource.Attributes.LongName,
+			ShortName: resource.Attributes.ShortName,
+			Color:     resource.Attributes.Color,
+			TextColor: resource.Attributes.TextColor,
+		}
+	}
+
+	// Flatten the route resources, naming buses by their number when they have no long name
+	routes := make([]models.Route, 0, len(response.Data))
+	for _, resource := range response.Data {
+		attributes := resource.Attributes
+		name := attributes.LongName
+		if name == "" {
+			name = attributes.ShortName
+		}
+		routes = append(routes, models.Route{
+			ID:                    resource.ID,
+			Name:                  name,
+			LongName:              attributes.LongName,
+			ShortName:             attributes.ShortName,
+			Description:           attributes.Description,
+			Color:                 attributes.Color,
+			TextColor:             attributes.TextColor,
+			Type:                  attributes.Type,
+			Mode:                  models.RouteTypeMode(attributes.Type),
+			SortOrder:             attributes.SortOrder,
+			DirectionNames:        attributes.DirectionNames,
+			DirectionDestinations: attributes.DirectionDestinations,
+			Line:                  lines[resource.Relationships.Line.Data.ID],
+		})
+	}
+
+	// Order the routes the way the MBTA lists them, e.g. subway lines before buses
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].SortOrder < routes[j].SortOrder
+	})
+
+	return routes, nil
+}
+
+// FetchLiveData fetches the live vehicle data for the given route IDs (comma separated) and/or route types from the MBTA API
+func (m *mbtaClientImpl) FetchLiveData(ctx context.Context, routeID string, routeTypes []int) ([]models.Vehicle, error) {
+	// Only add the filters that are set, the MBTA API returns every vehicle without filters
+	query := url.Values{}
+	if routeID != "" {
+		query.Set("filter[route]", routeID)
+	}
+	if len(routeTypes) > 0 {
+		query.Set("filter[route_type]", joinInts(routeTypes))
+	}
+	endpoint := fmt.Sprintf("%s/vehicles?%s", m.baseURL, query.Encode())
 	log.Println("endpoint is: ", endpoint)
 
-	data, err := m.fetchData(endpoint)
+	data, err := m.fetchData(ctx, endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching data: %w", err)
 	}
@@ -107,3 +202,218 @@ func (m *mbtaClientImpl) FetchLiveData(routeID string) ([]models.Vehicle, error)
 
 	return response.Data, nil
 }
+
+// FetchPredictions fetches the predictions for the given stop IDs and/or route IDs (comma separated) from the MBTA API,
+// ordered by predicted time. The MBTA API requires at least one of the filters.
+func (m *mbtaClientImpl) FetchPredictions(ctx context.Context, stopID, routeID string) ([]models.Prediction, error) {
+	query := url.Values{}
+	if stopID != "" {
+		query.Set("filter[stop]", stopID)
+	}
+	if routeID != "" {
+		query.Set("filter[route]", routeID)
+	}
+	return m.fetchPredictions(ctx, query)
+}
+
+// FetchTripPredictions fetches the predictions of the given trip from the MBTA API, ordered by predicted time
+func (m *mbtaClientImpl) FetchTripPredictions(ctx context.Context, tripID string) ([]models.Prediction, error) {
+	return m.fetchPredictions(ctx, url.Values{"filter[trip]": {tripID}})
+}
+
+// fetchPredictions fetches the predictions matching the given query filters, soonest first
+func (m *mbtaClientImpl) fetchPredictions(ctx context.Context, query url.Values) ([]models.Prediction, error) {
+	endpoint := fmt.Sprintf("%s/predictions?%s", m.baseURL, query.Encode())
+
+	data, err := m.fetchData(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch predictions: %w", err)
+	}
+
+	var response models.PredictionsResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshaling predictions: %w", err)
+	}
+
+	predictions := make([]models.Prediction, len(response.Data))
+	for i, resource := range response.Data {
+		predictions[i] = resource.Prediction()
+	}
+
+	// Soonest first, predictions without a time (e.g. cancelled trips) last
+	sort.SliceStable(predictions, func(i, j int) bool {
+		a, b := predictions[i].Time(), predictions[j].Time()
+		return a != nil && (b == nil || a.Before(*b))
+	})
+
+	return predictions, nil
+}
+
+// FetchTrip fetches a single trip from the MBTA API, failing with data.ErrNotFound if it does not exist
+func (m *mbtaClientImpl) FetchTrip(ctx context.Context, tripID string) (models.Trip, error) {
+	endpoint := fmt.Sprintf("%s/trips/%s", m.baseURL, url.PathEscape(tripID))
+
+	data, err := m.fetchData(ctx, endpoint)
+	if err != nil {
+		return models.Trip{}, fmt.Errorf("failed to fetch trip %s: %w", tripID, err)
+	}
+
+	var response models.TripResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return models.Trip{}, fmt.Errorf("error unmarshaling trip: %w", err)
+	}
+
+	return response.Data.Trip(), nil
+}
+
+// FetchTripVehicle fetches the vehicle serving the given trip from the MBTA API, or nil if no vehicle is assigned yet
+func (m *mbtaClientImpl) FetchTripVehicle(ctx context.Context, tripID string) (*models.Vehicle, error) {
+	endpoint := fmt.Sprintf("%s/vehicles?%s", m.baseURL, url.Values{"filter[trip]": {tripID}}.Encode())
+
+	data, err := m.fetchData(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch trip vehicle: %w", err)
+	}
+
+	var response models.VehicleResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshaling response: %w", err)
+	}
+	if len(response.Data) == 0 {
+		return nil, nil
+	}
+
+	// Populate the route field from relationships
+	vehicle := response.Data[0]
+	vehicle.Route = vehicle.RouteID()
+	return &vehicle, nil
+}
+
+// FetchShape fetches a single shape from the MBTA API and decodes it into latitude/longitude pairs
+func (m *mbtaClientImpl) FetchShape(ctx context.Context, shapeID string) ([][]float64, error) {
+	endpoint := fmt.Sprintf("%s/shapes/%s", m.baseURL, url.PathEscape(shapeID))
+
+	data, err := m.fetchData(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch shape %s: %w", shapeID, err)
+	}
+
+	var response struct {
+		Data models.Shape `json:"data"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshaling shape: %w", err)
+	}
+
+	decoded, err := pkg.DecodeShapes([]models.Shape{response.Data})
+	if err != nil || len(decoded) == 0 {
+		return nil, fmt.Errorf("failed to decode shape %s: %v", shapeID, err)
+	}
+	return decoded[0], nil
+}
+
+// FetchSchedules fetches the schedules matching the filter from the MBTA API, in scheduled order.
+// The trips of the schedules are requested in the same call with include=trip to give every schedule a headsign.
+func (m *mbtaClientImpl) FetchSchedules(ctx context.Context, filter models.ScheduleFilter) ([]models.Schedule, error) {
+	// The date is only sent if set: GetSchedules defaults it to today's service date, except for the schedules of a trip
+	query := url.Values{"include": {"trip"}}
+	if filter.Date != "" {
+		query.Set("filter[date]", filter.Date)
+	}
+	if filter.TripID != "" {
+		query.Set("filter[trip]", filter.TripID)
+	}
+	if filter.RouteID != "" {
+		query.Set("filter[route]", filter.RouteID)
+	}
+	if filter.StopID != "" {
+		query.Set("filter[stop]", filter.StopID)
+	}
+	if filter.DirectionID != nil {
+		query.Set("filter[direction_id]", strconv.Itoa(*filter.DirectionID))
+	}
+	endpoint := fmt.Sprintf("%s/schedules?%s", m.baseURL, query.Encode())
+
+	data, err := m.fetchData(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schedules: %w", err)
+	}
+
+	var response models.SchedulesResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshaling schedules: %w", err)
+	}
+
+	// Index the headsigns of the included trips
+	headsigns := make(map[string]string, len(response.Included))
+	for _, trip := range response.Included {
+		headsigns[trip.ID] = trip.Attributes.Headsign
+	}
+
+	schedules := make([]models.Schedule, len(response.Data))
+	for i, resource := range response.Data {
+		schedules[i] = resource.Schedule(headsigns[resource.Relationships.Trip.Data.ID])
+	}
+
+	// Earliest first, schedules without a time last
+	sort.SliceStable(schedules, func(i, j int) bool {
+		a, b := schedules[i].Time(), schedules[j].Time()
+		return a != nil && (b == nil || a.Before(*b))
+	})
+
+	return schedules, nil
+}
+
+// FetchAlerts fetches the alerts in effect now that match the filter from the MBTA API, most severe first.
+// Without an activity filter, the MBTA API only returns alerts affecting boarding, exiting or riding.
+func (m *mbtaClientImpl) FetchAlerts(ctx context.Context, filter models.AlertFilter) ([]models.Alert, error) {
+	// Only alerts active now, narrowed by the filters that are set
+	query := url.Values{"filter[datetime]": {"NOW"}}
+	if len(filter.RouteIDs) > 0 {
+		query.Set("filter[route]", strings.Join(filter.RouteIDs, ","))
+	}
+	if len(filter.StopIDs) > 0 {
+		query.Set("filter[stop]", strings.Join(filter.StopIDs, ","))
+	}
+	if len(filter.Activities) > 0 {
+		query.Set("filter[activity]", strings.Join(filter.Activities, ","))
+	}
+	if len(filter.Severities) > 0 {
+		query.Set("filter[severity]", joinInts(filter.Severities))
+	}
+	endpoint := fmt.Sprintf("%s/alerts?%s", m.baseURL, query.Encode())
+
+	data, err := m.fetchData(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch alerts: %w", err)
+	}
+
+	var response models.AlertsResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshaling alerts: %w", err)
+	}
+
+	alerts := make([]models.Alert, len(response.Data))
+	for i, resource := range response.Data {
+		alerts[i] = resource.Alert()
+	}
+
+	// Most severe first, then most recently updated
+	sort.SliceStable(alerts, func(i, j int) bool {
+		if alerts[i].Severity != alerts[j].Severity {
+			return alerts[i].Severity > alerts[j].Severity
+		}
+		return alerts[i].UpdatedAt.After(alerts[j].UpdatedAt)
+	})
+
+	return alerts, nil
+}
+
+// joinInts formats integers as a comma separated list for MBTA API filters
+func joinInts(values []int) string {
+	strValues := make([]string, len(values))
+	for i, value := range values {
+		strValues[i] = strconv.Itoa(value)
+	}
+	return strings.Join(strValues, ",")
+}
@@ -0,0 +1,132 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"explorer/internal/adapters/mbta/fake"
+	"explorer/internal/ports/data"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newFakeClient starts the fake MBTA API and returns a client pointed at it, along with the
+// API key header of every request the fake server received.
+func newFakeClient(t *testing.T, apiKey string) (data.MBTAClient, func() []string) {
+	t.Helper()
+	server, err := fake.NewServer(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mutex sync.Mutex
+	var keys []string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		keys = append(keys, r.Header.Get("x-api-key"))
+		mutex.Unlock()
+		server.ServeHTTP(w, r)
+	}))
+	t.Cleanup(httpServer.Close)
+
+	return NewMBTAClient(apiKey, httpServer.URL), func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), keys...)
+	}
+}
+
+func TestMBTAClientAgainstFakeServer(t *testing.T) {
+	client, _ := newFakeClient(t, "")
+	ctx := context.Background()
+
+	stops, err := client.FetchStops(ctx, "Red")
+	if err != nil || len(stops) != 7 {
+		t.Errorf("FetchStops(Red) = %d stops, %v, want 7", len(stops), err)
+	}
+
+	routes, err := client.FetchRoutes(ctx, []int{1})
+	if err != nil || len(routes) != 1 || routes[0].ID != "Red" || routes[0].Line == nil {
+		t.Errorf("FetchRoutes(1) = %+v, %v, want the Red Line with its line", routes, err)
+	}
+
+	vehicles, err := client.FetchLiveData(ctx, "Red", nil)
+	if err != nil || len(vehicles) != 3 {
+		t.Fatalf("FetchLiveData(Red) = %d vehicles, %v, want 3", len(vehicles), err)
+	}
+	for _, vehicle := range vehicles {
+		if vehicle.Route != "Red" {
+			t.Errorf("vehicle %s route = %q, want Red", vehicle.ID, vehicle.Route)
+		}
+	}
+
+	trip, err := client.FetchTrip(ctx, "67268866")
+	if err != nil || trip.ID != "67268866" {
+		t.Errorf("FetchTrip(67268866) = %+v, %v", trip, err)
+	}
+	if _, err := client.FetchTrip(ctx, "no-such-trip"); !errors.Is(err, data.ErrNotFound) {
+		t.Errorf("FetchTrip(no-such-trip) error = %v, want ErrNotFound", err)
+	}
+
+	vehicle, err := client.FetchTripVehicle(ctx, "67268866")
+	if err != nil || vehicle == nil || vehicle.ID != "R-5482A1B0" {
+		t.Errorf("FetchTripVehicle(67268866) = %+v, %v, want R-5482A1B0", vehicle, err)
+	}
+
+	// Trip IDs are escaped, so one cannot add filters of its own
+	vehicle, err = client.FetchTripVehicle(ctx, "67268866&filter[route]=Red")
+	if err != nil || vehicle != nil {
+		t.Errorf("FetchTripVehicle with a crafted trip ID = %+v, %v, want no vehicle", vehicle, err)
+	}
+}
+
+func TestMBTAClientSendsAPIKeyOnlyWhenSet(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey string
+	}{
+		{name: "with key", apiKey: "secret"},
+		{name: "without key", apiKey: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, received := newFakeClient(t, tt.apiKey)
+			if _, err := client.FetchStops(context.Background(), "Red"); err != nil {
+				t.Fatal(err)
+			}
+			if keys := received(); len(keys) != 1 || keys[0] != tt.apiKey {
+				t.Errorf("x-api-key headers = %q, want %q", keys, tt.apiKey)
+			}
+		})
+	}
+}
+
+func TestMBTAClientCancelledRequest(t *testing.T) {
+	client, _ := newFakeClient(t, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := client.FetchStops(ctx, "Red"); !errors.Is(err, context.Canceled) {
+		t.Errorf("FetchStops with a cancelled context error = %v, want context.Canceled", err)
+	}
+}
+
+func TestMBTAClientMalformedResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":`))
+	}))
+	defer server.Close()
+	client := NewMBTAClient("", server.URL)
+	ctx := context.Background()
+
+	// Malformed responses fail the request instead of stopping the server
+	if _, err := client.FetchStops(ctx, "Red"); err == nil {
+		t.Error("FetchStops succeeded on a malformed response")
+	}
+	if _, err := client.FetchShapes(ctx, "Red"); err == nil {
+		t.Error("FetchShapes succeeded on a malformed response")
+	}
+}
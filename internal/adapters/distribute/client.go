@@ -0,0 +1,224 @@
+package distribute
+
+import (
+	"explorer/internal/core/domain/models"
+	"log"
+	"sync"
+)
+
+// clientState tracks a connected client: its channel, filter, the vehicles it currently
+// knows about, and how many events it failed to keep up with.
+type clientState struct {
+	mutex  sync.Mutex // Guards every field below while rendering and sending
+	ch     chan models.StreamEvent
+	closed bool // Whether ch has been closed by RemoveClient
+	filter models.VehicleFilter
+
+	// IDs of vehicles sent to the client and not yet removed. It is nil when the client's
+	// vehicles are unknown (e.g. after resuming from Last-Event-ID), in which case removes
+	// are always forwarded until the next reset.
+	visible map[string]struct{}
+
+	drops            uint64 // Total number of events dropped for this client
+	consecutiveDrops int    // Number of events dropped since the last successful send
+
+	// Events held back by the coalesce policy or the throttle, oldest first, and the latest
+	// pending event per resource (e.g. vehicle).
+	pending           []*clientEvent
+	pendingByResource map[string]*clientEvent
+
+	throttled bool          // Whether events are held and flushed on a ticker rather than sent immediately
+	retrying  bool          // Whether events held back by the coalesce policy are being retried
+	done      chan struct{} // Closed when the client is removed, stopping its flush ticker
+}
+
+// clientEvent is an event rendered for a client along with the resource it concerns, used for coalescing.
+type clientEvent struct {
+	event      models.StreamEvent
+	resourceID string // The vehicle, prediction or alert the event concerns, empty for resets
+	reset      bool   // Whether the event replaces the client's entire resource set
+}
+
+// newClientState initializes the state for a client channel using the given filter.
+func newClientState(ch chan models.StreamEvent, filter models.VehicleFilter) *clientState {
+	return &clientState{
+		ch:      ch,
+		filter:  filter,
+		visible: make(map[string]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// render returns the events the client should receive for the given event.
+//
+// Parameters:
+// - event: The broadcast event.
+//
+// Returns:
+//   - The events to send, in order. Unfiltered clients receive the event unchanged.
+//     Filtered clients only receive matching vehicles, plus a synthesized remove when
+//     a vehicle they know about stops matching (e.g. it changes direction). Prediction
+//     and alert streams are not filtered per client, each query having its own upstream stream.
+func (c *clientState) render(event models.StreamEvent) []clientEvent {
+	switch e := event.(type) {
+	case models.VehicleReset:
+		c.visible = make(map[string]struct{})
+		if c.filter.IsEmpty() {
+			for _, vehicle := range e.Vehicles {
+				c.visible[vehicle.ID] = struct{}{}
+			}
+			return []clientEvent{{event: e, reset: true}}
+		}
+
+		// Rebuild the reset with only the matching vehicles.
+		kept := make([]models.Vehicle, 0, len(e.Vehicles))
+		for _, vehicle := range e.Vehicles {
+			if c.filter.Matches(vehicle) {
+				c.visible[vehicle.ID] = struct{}{}
+				kept = append(kept, vehicle)
+			}
+		}
+		e.Vehicles = kept
+		return []clientEvent{{event: e, reset: true}}
+
+	case models.VehicleAdded:
+		return c.renderVehicle(e, e.Vehicle)
+
+	case models.VehicleUpdated:
+		return c.renderVehicle(e, e.Vehicle)
+
+	case models.VehicleRemoved:
+		if c.knows(e.VehicleID) {
+			delete(c.visible, e.VehicleID)
+			return []clientEvent{{event: e, resourceID: e.VehicleID}}
+		}
+
+	case models.PredictionReset:
+		return []clientEvent{{event: e, reset: true}}
+
+	case models.PredictionAdded:
+		return []clientEvent{{event: e, resourceID: e.Prediction.ID}}
+
+	case models.PredictionUpdated:
+		return []clientEvent{{event: e, resourceID: e.Prediction.ID}}
+
+	case models.PredictionRemoved:
+		return []clientEvent{{event: e, resourceID: e.PredictionID}}
+
+	case models.AlertReset:
+		return []clientEvent{{event: e, reset: true}}
+
+	case models.AlertAdded:
+		return []clientEvent{{event: e, resourceID: e.Alert.ID}}
+
+	case models.AlertUpdated:
+		return []clientEvent{{event: e, resourceID: e.Alert.ID}}
+
+	case models.AlertRemoved:
+		return []clientEvent{{event: e, resourceID: e.AlertID}}
+	}
+
+	return nil
+}
+
+// renderVehicle returns the events the client should receive for an add or update of vehicle.
+func (c *clientState) renderVehicle(event models.StreamEvent, vehicle models.Vehicle) []clientEvent {
+	if c.filter.Matches(vehicle) {
+		if c.visible != nil {
+			c.visible[vehicle.ID] = struct{}{}
+		}
+		return []clientEvent{{event: event, resourceID: vehicle.ID}}
+	}
+	if c.knows(vehicle.ID) {
+		// The vehicle no longer matches, so tell the client to drop it.
+		delete(c.visible, vehicle.ID)
+		removed := models.VehicleRemoved{ID: event.EventID(), VehicleID: vehicle.ID}
+		return []clientEvent{{event: removed, resourceID: vehicle.ID}}
+	}
+	return nil
+}
+
+// knows reports whether the client may have the given vehicle. When the client's
+// vehicles are unknown, every vehicle is assumed to be known.
+func (c *clientState) knows(vehicleID string) bool {
+	if c.visible == nil {
+		return true
+	}
+	_, ok := c.visible[vehicleID]
+	return ok
+}
+
+// recordDrop counts a dropped event, logging only when the client starts falling behind.
+func (c *clientState) recordDrop() {
+	if c.consecutiveDrops == 0 {
+		log.Println("Stream client is slow, dropping events...")
+	}
+	c.drops++
+	c.consecutiveDrops++
+}
+
+// coalesce holds an event until it can be sent, replacing any pending event for the same
+// resource. A reset supersedes everything pending before it.
+//
+// Returns:
+// - The number of pending events superseded by this one, which never reach the client.
+func (c *clientState) coalesce(event clientEvent) uint64 {
+	var superseded uint64
+	if c.pendingByResource == nil || event.reset {
+		superseded = uint64(len(c.pending)) // Anything pending is superseded by the reset
+		c.pending = nil
+		c.pendingByResource = make(map[string]*clientEvent)
+	}
+
+	if previous, ok := c.pendingByResource[event.resourceID]; ok && event.resourceID != "" {
+		// The older event for this resource is superseded and never reaches the client.
+		previous.event = mergeEvents(previous.event, event.event)
+		return superseded + 1
+	}
+
+	c.pending = append(c.pending, &event)
+	if event.resourceID != "" {
+		c.pendingByResource[event.resourceID] = &event
+	}
+	return superseded
+}
+
+// mergeEvents returns the event replacing a pending event for the same resource. An update
+// of a resource whose add is still pending stays an add, since the client does not have it yet.
+func mergeEvents(pending, next models.StreamEvent) models.StreamEvent {
+	switch update := next.(type) {
+	case models.VehicleUpdated:
+		if _, added := pending.(models.VehicleAdded); added {
+			return models.VehicleAdded{ID: update.ID, Vehicle: update.Vehicle}
+		}
+	case models.PredictionUpdated:
+		if _, added := pending.(models.PredictionAdded); added {
+			return models.PredictionAdded{ID: update.ID, Prediction: update.Prediction}
+		}
+	case models.AlertUpdated:
+		if _, added := pending.(models.AlertAdded); added {
+			return models.AlertAdded{ID: update.ID, Alert: update.Alert}
+		}
+	}
+	return next
+}
+
+// flushPending sends as many pending events as fit in the client's channel.
+//
+// Returns:
+// - true if events are still pending.
+func (c *clientState) flushPending() bool {
+	for len(c.pending) > 0 {
+		event := c.pending[0]
+		select {
+		case c.ch <- event.event:
+			if c.pendingByResource[event.resourceID] == event {
+				delete(c.pendingByResource, event.resourceID)
+			}
+			c.pending = c.pending[1:]
+		default:
+			return true
+		}
+	}
+	return false
+}
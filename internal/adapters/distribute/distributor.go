@@ -1,64 +1,210 @@
 package distribute
 
 import (
+	"explorer/internal/core/domain/models"
+	ports "explorer/internal/ports/streaming"
 	"log"
 	"sync"
+	"time"
 )
 
+// historySize is the number of recent events kept for replay to reconnecting clients.
+const historySize = 1000
+
 type ClientDistributor struct {
-	clients      map[chan string]struct{}
+	clients      map[chan models.StreamEvent]*clientState
 	clientsMutex sync.Mutex
-	stop         chan struct{} // Channel to signal when to stop streaming
+	history      *history           // Recent numbered events, guarded by clientsMutex
+	options      DistributorOptions // Slow consumer handling
+	stop         chan struct{}      // Channel to signal when to stop streaming
+
+	broadcasts   uint64 // Number of broadcast events, guarded by clientsMutex
+	removedDrops uint64 // Dropped events of clients that have been removed, guarded by clientsMutex
 }
 
-func NewClientDistributor() *ClientDistributor {
+// NewClientDistributor initializes a ClientDistributor that handles slow clients according to options.
+func NewClientDistributor(options DistributorOptions) *ClientDistributor {
 	return &ClientDistributor{
-		clients: make(map[chan string]struct{}),
+		clients: make(map[chan models.StreamEvent]*clientState),
+		history: newHistory(historySize),
+		options: options,
 		stop:    make(chan struct{}),
 	}
 }
 
-// Broadcast sends the given data to all connected clients.
-// This method ensures thread-safe access to the client map and skips clients
-// that are unable to keep up with the data flow.
-func (cd *ClientDistributor) Broadcast(data string) {
-	// Acquire the mutex lock to safely access the client map.
+// Broadcast sends the given event to all connected clients.
+// The client map is only locked while taking a copy of the clients, so a slow client
+// cannot stall the fan-out. Each client's filter is applied and clients that are unable
+// to keep up with the data flow are handled according to the slow consumer policy.
+func (cd *ClientDistributor) Broadcast(event models.StreamEvent) {
+	// Acquire the mutex lock to safely access the client map and history.
 	cd.clientsMutex.Lock()
-	defer cd.clientsMutex.Unlock() // Ensure the mutex is unlocked after the operation.
+	cd.broadcasts++
+	if event.EventID() != 0 {
+		cd.history.add(event) // Keep numbered events so reconnecting clients can catch up.
+	}
+	clients := make([]*clientState, 0, len(cd.clients))
+	for _, state := range cd.clients {
+		clients = append(clients, state)
+	}
+	cd.clientsMutex.Unlock()
 
-	// Iterate over all registered clients.
-	for client := range cd.clients {
-		select {
-		case client <- data:
-			// Attempt to send data to the client's channel.
-			// If the client is ready to receive, this operation succeeds immediately.
-		default:
-			// Skip clients whose channels are full or unresponsive.
-			// Log a message to indicate the client was skipped due to being slow.
-			log.Println("Stream Manager Client is slow, skipping...")
+	// Iterate over the registered clients, collecting those that fell too far behind.
+	var slow []chan models.StreamEvent
+	for _, state := range clients {
+		state.mutex.Lock()
+		if !state.closed && cd.deliver(state, state.render(event)) {
+			slow = append(slow, state.ch)
 		}
+		state.mutex.Unlock()
+	}
+
+	// Disconnect slow clients so they can reconnect and resynchronize.
+	for _, client := range slow {
+		cd.RemoveClient(client)
 	}
 }
 
 // AddClient adds a new client channel to the manager to receive data updates.
-// It locks the client list to ensure thread safety during modification.
-func (cd *ClientDistributor) AddClient(client chan string) {
-	cd.clientsMutex.Lock()          // Lock to ensure safe access to the clients map
-	defer cd.clientsMutex.Unlock()  // Unlock once the operation is done
-	cd.clients[client] = struct{}{} // Add the client channel to the map of active clients
+// If opts.LastEventID is set and every later event is still buffered, the missed events
+// are replayed. Otherwise, if opts.Snapshot is set, its result is filtered and sent before
+// any live data. This happens while holding the client lock, so no broadcast can slip in
+// between the initial events and the registration of the client.
+// If opts.FlushInterval is set, live events for the client are then held and only the
+// latest event per vehicle (or prediction) is sent once per interval.
+func (cd *ClientDistributor) AddClient(client chan models.StreamEvent, opts ports.ClientOptions) {
+	cd.clientsMutex.Lock()         // Lock to ensure safe access to the clients map
+	defer cd.clientsMutex.Unlock() // Unlock once the operation is done
+
+	state := newClientState(client, opts.Filter)
+	if !cd.replay(state, opts.LastEventID) {
+		cd.sendSnapshot(state, opts.Snapshot) // Queue the initial event ahead of any live data
+	}
+
+	// Throttle live data once the initial events are queued, so the client is never kept waiting for them.
+	if opts.FlushInterval > 0 {
+		state.throttled = true
+		go cd.flushPeriodically(state, opts.FlushInterval)
+	}
+
+	cd.clients[client] = state // Add the client channel to the map of active clients
+}
+
+// flushPeriodically sends the events held for a throttled client once per interval, until
+// the client is removed. Between ticks only the latest event per vehicle (or prediction) is kept, and events
+// that do not fit in the client's channel stay pending until the next tick.
+func (cd *ClientDistributor) flushPeriodically(state *clientState, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			state.mutex.Lock()
+			if !state.closed {
+				state.flushPending()
+			}
+			state.mutex.Unlock()
+		case <-state.done:
+			return
+		}
+	}
+}
+
+// UpdateClient replaces the options of a connected client, e.g. when it subscribes to
+// different routes at runtime. If opts.Snapshot is set, its result is filtered with the
+// new options and sent so the client can rebuild its view of the vehicles.
+func (cd *ClientDistributor) UpdateClient(client chan models.StreamEvent, opts ports.ClientOptions) {
+	cd.clientsMutex.Lock()
+	defer cd.clientsMutex.Unlock()
+
+	// Ignore clients that have already been removed
+	state, ok := cd.clients[client]
+	if !ok {
+		return
+	}
+
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	state.filter = opts.Filter
+	cd.sendSnapshot(state, opts.Snapshot)
+}
+
+// replay sends the buffered events after lastEventID to a reconnecting client.
+// The caller must hold clientsMutex.
+//
+// Returns:
+// - true if the client was caught up, false if it needs a snapshot instead.
+func (cd *ClientDistributor) replay(state *clientState, lastEventID uint64) bool {
+	if lastEventID == 0 {
+		return false
+	}
+	missed, ok := cd.history.since(lastEventID)
+	if !ok || len(missed) > cap(state.ch) {
+		// Too far behind, or more missed events than the client channel can hold.
+		return false
+	}
+
+	// The client's vehicles are not known to us, so forward removes until the next reset.
+	state.visible = nil
+	for _, event := range missed {
+		cd.deliver(state, state.render(event))
+	}
+	return true
+}
+
+// sendSnapshot renders the snapshot event for a client and sends it, skipping it if
+// there is no snapshot or nothing to send. The snapshot is numbered with the ID of
+// the last broadcast event so the client can resume from it. The caller must hold clientsMutex.
+func (cd *ClientDistributor) sendSnapshot(state *clientState, snapshot func() models.StreamEvent) {
+	if snapshot == nil {
+		return
+	}
+	if event := snapshot(); event != nil {
+		cd.deliver(state, state.render(event.WithID(cd.history.lastID())))
+	}
 }
 
 // RemoveClient removes a client channel when they disconnect.
 // It locks the client list to ensure thread safety during modification.
-func (cd *ClientDistributor) RemoveClient(client chan string) {
+func (cd *ClientDistributor) RemoveClient(client chan models.StreamEvent) {
 	cd.clientsMutex.Lock()         // Lock to ensure safe access to the clients map
 	defer cd.clientsMutex.Unlock() // Unlock once the operation is done
 	// Check if the client exists in the map
-	if _, ok := cd.clients[client]; ok {
-		// Remove the client from the map and close the channel to signal disconnection
+	if state, ok := cd.clients[client]; ok {
+		// Remove the client from the map and close the channel to signal disconnection.
+		// The client's mutex ensures no broadcast is writing to the channel as it closes.
 		delete(cd.clients, client)
+		state.mutex.Lock()
+		state.closed = true
 		close(client)
+		close(state.done)
+		if state.drops > 0 {
+			log.Printf("Stream client removed after %d dropped events", state.drops)
+			cd.removedDrops += state.drops
+		}
+		state.mutex.Unlock()
+	}
+}
+
+// DistributorStats returns the number of clients, broadcasts and dropped events.
+func (cd *ClientDistributor) DistributorStats() models.StreamDistributorStats {
+	cd.clientsMutex.Lock()
+	defer cd.clientsMutex.Unlock()
+
+	stats := models.StreamDistributorStats{
+		Clients:         len(cd.clients),
+		Broadcasts:      cd.broadcasts,
+		DroppedMessages: cd.removedDrops,
+		ClientDrops:     make([]uint64, 0, len(cd.clients)),
+	}
+	for _, state := range cd.clients {
+		state.mutex.Lock()
+		stats.DroppedMessages += state.drops
+		stats.ClientDrops = append(stats.ClientDrops, state.drops)
+		state.mutex.Unlock()
 	}
+	return stats
 }
 
 // Stop stops the stream manager and signals all processes to stop.
@@ -0,0 +1,139 @@
+package distribute
+
+import (
+	"explorer/internal/core/domain/models"
+	ports "explorer/internal/ports/streaming"
+	"slices"
+	"testing"
+	"time"
+)
+
+// receiveAll returns the events queued for the client, waiting briefly for each
+func receiveAll(client chan models.StreamEvent) []models.StreamEvent {
+	var events []models.StreamEvent
+	for {
+		select {
+		case event := <-client:
+			events = append(events, event)
+		case <-time.After(20 * time.Millisecond):
+			return events
+		}
+	}
+}
+
+// emptySnapshot returns an empty vehicle reset, standing in for the stream store's snapshot
+func emptySnapshot() models.StreamEvent {
+	return models.VehicleReset{}
+}
+
+func TestAddClientResumesFromLastEventID(t *testing.T) {
+	distributor := NewClientDistributor(DefaultDistributorOptions())
+	for id := range uint64(historySize + 100) {
+		distributor.Broadcast(vehicleUpdate(id+1, "R-1"))
+	}
+	newest := uint64(historySize + 100)
+
+	tests := []struct {
+		name        string
+		lastEventID uint64
+		want        []uint64 // IDs of the events replayed, nil when a snapshot is sent instead
+	}{
+		{name: "missed events", lastEventID: newest - 3, want: []uint64{newest - 2, newest - 1, newest}},
+		{name: "caught up", lastEventID: newest, want: []uint64{}},
+		{name: "evicted from the history", lastEventID: 50},
+		{name: "unknown", lastEventID: newest + 1},
+		{name: "none", lastEventID: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := make(chan models.StreamEvent, 10)
+			distributor.AddClient(client, ports.ClientOptions{LastEventID: tt.lastEventID, Snapshot: emptySnapshot})
+			defer distributor.RemoveClient(client)
+
+			events := receiveAll(client)
+			if tt.want != nil {
+				if ids := eventIDs(events); !slices.Equal(ids, tt.want) {
+					t.Errorf("replayed events %v, want %v", ids, tt.want)
+				}
+				return
+			}
+
+			// Without the missed events, the client starts over from a snapshot it can resume from
+			if len(events) != 1 || events[0].EventName() != models.ResetEvent || events[0].EventID() != newest {
+				t.Fatalf("got events %v, want a single reset numbered %d", eventIDs(events), newest)
+			}
+		})
+	}
+}
+
+func TestAddClientFallsBackToSnapshotWhenReplayDoesNotFit(t *testing.T) {
+	distributor := NewClientDistributor(DefaultDistributorOptions())
+	for id := range uint64(20) {
+		distributor.Broadcast(vehicleUpdate(id+1, "R-1"))
+	}
+
+	// The client channel cannot hold the 10 missed events
+	client := make(chan models.StreamEvent, 5)
+	distributor.AddClient(client, ports.ClientOptions{LastEventID: 10, Snapshot: emptySnapshot})
+	defer distributor.RemoveClient(client)
+
+	events := receiveAll(client)
+	if len(events) != 1 || events[0].EventName() != models.ResetEvent {
+		t.Errorf("got events %v, want a single reset", eventIDs(events))
+	}
+}
+
+func TestClientReceivesRemoveWhenVehicleLeavesFilter(t *testing.T) {
+	distributor := NewClientDistributor(DefaultDistributorOptions())
+	southbound := 0
+	client := make(chan models.StreamEvent, 10)
+	distributor.AddClient(client, ports.ClientOptions{
+		Filter:   models.VehicleFilter{RouteIDs: []string{"Red"}, Direction: &southbound},
+		Snapshot: emptySnapshot,
+	})
+	defer distributor.RemoveClient(client)
+	receiveAll(client) // The initial reset
+
+	vehicle := func(id string, route string, direction int) models.Vehicle {
+		return models.Vehicle{ID: id, Route: route, Attributes: models.VehicleAttributes{DirectionID: direction}}
+	}
+	distributor.Broadcast(models.VehicleAdded{ID: 1, Vehicle: vehicle("R-1", "Red", 0)})
+	distributor.Broadcast(models.VehicleAdded{ID: 2, Vehicle: vehicle("R-2", "Red", 0)})
+	distributor.Broadcast(models.VehicleAdded{ID: 3, Vehicle: vehicle("O-1", "Orange", 0)}) // Never matched
+	distributor.Broadcast(models.VehicleUpdated{ID: 4, Vehicle: vehicle("R-1", "Red", 1)})  // Turned around at a terminal
+	distributor.Broadcast(models.VehicleUpdated{ID: 5, Vehicle: vehicle("R-1", "Red", 1)})  // Already removed
+	distributor.Broadcast(models.VehicleUpdated{ID: 6, Vehicle: vehicle("R-2", "Blue", 0)}) // Reassigned to another route
+	distributor.Broadcast(models.VehicleUpdated{ID: 7, Vehicle: vehicle("R-1", "Red", 0)})  // Back in the filter
+
+	want := []struct {
+		id        uint64
+		name      string
+		vehicleID string
+	}{
+		{id: 1, name: models.AddedEvent, vehicleID: "R-1"},
+		{id: 2, name: models.AddedEvent, vehicleID: "R-2"},
+		{id: 4, name: models.RemovedEvent, vehicleID: "R-1"},
+		{id: 6, name: models.RemovedEvent, vehicleID: "R-2"},
+		{id: 7, name: models.UpdatedEvent, vehicleID: "R-1"},
+	}
+	events := receiveAll(client)
+	if len(events) != len(want) {
+		t.Fatalf("got events %v, want %d events", eventIDs(events), len(want))
+	}
+	for i, w := range want {
+		event := events[i]
+		var vehicleID string
+		switch e := event.(type) {
+		case models.VehicleAdded:
+			vehicleID = e.Vehicle.ID
+		case models.VehicleUpdated:
+			vehicleID = e.Vehicle.ID
+		case models.VehicleRemoved:
+			vehicleID = e.VehicleID
+		}
+		if event.EventID() != w.id || event.EventName() != w.name || vehicleID != w.vehicleID {
+			t.Errorf("event %d = %d %s %s, want %d %s %s", i, event.EventID(), event.EventName(), vehicleID, w.id, w.name, w.vehicleID)
+		}
+	}
+}
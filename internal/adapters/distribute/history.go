@@ -0,0 +1,64 @@
+package distribute
+
+import "explorer/internal/core/domain/models"
+
+// history is a bounded ring buffer of the most recent numbered events,
+// used to replay missed events to clients reconnecting with Last-Event-ID.
+type history struct {
+	events []models.StreamEvent
+	start  int // Index of the oldest event
+	size   int // Number of events currently buffered
+}
+
+// newHistory initializes a history holding at most capacity events.
+func newHistory(capacity int) *history {
+	return &history{events: make([]models.StreamEvent, capacity)}
+}
+
+// add appends an event, overwriting the oldest one when the buffer is full.
+func (h *history) add(event models.StreamEvent) {
+	if len(h.events) == 0 {
+		return
+	}
+	end := (h.start + h.size) % len(h.events)
+	h.events[end] = event
+	if h.size < len(h.events) {
+		h.size++
+	} else {
+		h.start = (h.start + 1) % len(h.events)
+	}
+}
+
+// since returns the buffered events with an ID greater than lastID, oldest first.
+//
+// Returns:
+//   - The events to replay, and false if events after lastID are no longer buffered
+//     (or lastID is unknown), meaning the client needs a full snapshot instead.
+func (h *history) since(lastID uint64) ([]models.StreamEvent, bool) {
+	if h.size == 0 {
+		return nil, false
+	}
+
+	oldest := h.events[h.start]
+	newest := h.events[(h.start+h.size-1)%len(h.events)]
+	if lastID+1 < oldest.EventID() || lastID > newest.EventID() {
+		return nil, false
+	}
+
+	var missed []models.StreamEvent
+	for i := 0; i < h.size; i++ {
+		event := h.events[(h.start+i)%len(h.events)]
+		if event.EventID() > lastID {
+			missed = append(missed, event)
+		}
+	}
+	return missed, true
+}
+
+// lastID returns the ID of the newest buffered event, or 0 if the buffer is empty.
+func (h *history) lastID() uint64 {
+	if h.size == 0 {
+		return 0
+	}
+	return h.events[(h.start+h.size-1)%len(h.events)].EventID()
+}
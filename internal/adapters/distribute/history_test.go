@@ -0,0 +1,53 @@
+package distribute
+
+import (
+	"explorer/internal/core/domain/models"
+	"slices"
+	"testing"
+)
+
+// eventIDs returns the IDs of the given events
+func eventIDs(events []models.StreamEvent) []uint64 {
+	ids := make([]uint64, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.EventID())
+	}
+	return ids
+}
+
+func TestHistorySince(t *testing.T) {
+	// A ring of 5 events after 8 were added holds events 4 to 8
+	h := newHistory(5)
+	for id := range uint64(8) {
+		h.add(vehicleUpdate(id+1, "R-1"))
+	}
+
+	tests := []struct {
+		name   string
+		lastID uint64
+		want   []uint64
+		ok     bool
+	}{
+		{name: "caught up", lastID: 8, want: nil, ok: true},
+		{name: "missed some", lastID: 5, want: []uint64{6, 7, 8}, ok: true},
+		{name: "missed every buffered event", lastID: 3, want: []uint64{4, 5, 6, 7, 8}, ok: true},
+		{name: "evicted", lastID: 2, ok: false},
+		{name: "unknown", lastID: 9, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missed, ok := h.since(tt.lastID)
+			if ok != tt.ok || !slices.Equal(eventIDs(missed), tt.want) {
+				t.Errorf("since(%d) = %v, %v, want %v, %v", tt.lastID, eventIDs(missed), ok, tt.want, tt.ok)
+			}
+		})
+	}
+
+	if missed, ok := newHistory(5).since(1); ok || missed != nil {
+		t.Errorf("since on an empty history = %v, %v, want a snapshot", eventIDs(missed), ok)
+	}
+	if lastID := h.lastID(); lastID != 8 {
+		t.Errorf("lastID = %d, want 8", lastID)
+	}
+}
@@ -0,0 +1,149 @@
+package distribute
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// SlowConsumerPolicy decides what happens to events for a client whose channel is full.
+type SlowConsumerPolicy string
+
+const (
+	// DropNewest discards the event that does not fit in the client's channel.
+	DropNewest SlowConsumerPolicy = "drop-newest"
+
+	// DropOldest discards the oldest queued event to make room for the new one.
+	DropOldest SlowConsumerPolicy = "drop-oldest"
+
+	// Disconnect discards the event and disconnects the client after MaxDrops consecutive drops,
+	// so it can reconnect and resynchronize from a snapshot.
+	Disconnect SlowConsumerPolicy = "disconnect"
+
+	// Coalesce holds events that do not fit and keeps only the latest one per vehicle (or prediction),
+	// retrying them every RetryInterval until the client catches up. No state is lost.
+	Coalesce SlowConsumerPolicy = "coalesce"
+)
+
+// DistributorOptions configures a ClientDistributor.
+type DistributorOptions struct {
+	Policy        SlowConsumerPolicy // How to handle clients whose channel is full
+	MaxDrops      int                // Consecutive drops tolerated before a client is disconnected
+	RetryInterval time.Duration      // How often events held back by the coalesce policy are retried, 0 to only retry on the next broadcast
+}
+
+// DefaultDistributorOptions returns the options used when none are configured.
+func DefaultDistributorOptions() DistributorOptions {
+	return DistributorOptions{
+		Policy:        Coalesce,
+		MaxDrops:      50,
+		RetryInterval: 100 * time.Millisecond,
+	}
+}
+
+// ParseSlowConsumerPolicy converts a policy name (e.g., from configuration) into a SlowConsumerPolicy.
+// An empty name selects the default policy.
+func ParseSlowConsumerPolicy(name string) (SlowConsumerPolicy, error) {
+	switch policy := SlowConsumerPolicy(name); policy {
+	case "":
+		return DefaultDistributorOptions().Policy, nil
+	case DropNewest, DropOldest, Disconnect, Coalesce:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown slow consumer policy %q", name)
+	}
+}
+
+// deliver sends events to a client, applying the slow consumer policy when its channel is full.
+// The caller must hold the client's mutex.
+//
+// Returns:
+// - true if the client exceeded the tolerated drops and should be disconnected.
+func (cd *ClientDistributor) deliver(state *clientState, events []clientEvent) bool {
+	for _, event := range events {
+		// Throttled clients receive the latest pending events on their next tick.
+		if state.throttled {
+			state.coalesce(event)
+			continue
+		}
+
+		// Once events are pending, new ones queue behind them to preserve ordering.
+		if cd.options.Policy == Coalesce && state.flushPending() {
+			state.drops += state.coalesce(event)
+			cd.startRetrying(state)
+			continue
+		}
+
+		select {
+		case state.ch <- event.event:
+			state.consecutiveDrops = 0
+			continue
+		default:
+		}
+
+		// The client's channel is full.
+		switch cd.options.Policy {
+		case Coalesce:
+			state.drops += state.coalesce(event)
+			cd.startRetrying(state)
+			continue
+
+		case DropOldest:
+			// Make room by discarding the oldest queued event, then retry once.
+			select {
+			case <-state.ch:
+			default:
+			}
+			state.recordDrop()
+			select {
+			case state.ch <- event.event:
+			default:
+				state.recordDrop()
+			}
+
+		case Disconnect:
+			state.recordDrop()
+			if state.consecutiveDrops >= cd.options.MaxDrops {
+				log.Printf("Stream client dropped %d consecutive events, disconnecting", state.consecutiveDrops)
+				return true
+			}
+
+		default: // DropNewest
+			state.recordDrop()
+		}
+	}
+	return false
+}
+
+// startRetrying starts retrying the events held back for an unthrottled client, unless it is
+// already being retried, so they reach the client once it catches up even if nothing else is broadcast.
+// The caller must hold the client's mutex.
+func (cd *ClientDistributor) startRetrying(state *clientState) {
+	if state.retrying || state.throttled || cd.options.RetryInterval <= 0 {
+		return
+	}
+	state.retrying = true
+	go cd.retryPending(state)
+}
+
+// retryPending sends the events held back for a client once per RetryInterval, until none
+// are left or the client is removed.
+func (cd *ClientDistributor) retryPending(state *clientState) {
+	ticker := time.NewTicker(cd.options.RetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			state.mutex.Lock()
+			if state.closed || !state.flushPending() {
+				state.retrying = false
+				state.mutex.Unlock()
+				return
+			}
+			state.mutex.Unlock()
+		case <-state.done:
+			return
+		}
+	}
+}
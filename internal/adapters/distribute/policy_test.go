@@ -0,0 +1,100 @@
+package distribute
+
+import (
+	"explorer/internal/core/domain/models"
+	ports "explorer/internal/ports/streaming"
+	"testing"
+	"time"
+)
+
+// vehicleUpdate returns an update event of the given vehicle
+func vehicleUpdate(id uint64, vehicleID string) models.StreamEvent {
+	return models.VehicleUpdated{ID: id, Vehicle: models.Vehicle{ID: vehicleID}}
+}
+
+func TestCoalescedEventsReachClientWithoutFurtherBroadcasts(t *testing.T) {
+	distributor := NewClientDistributor(DistributorOptions{Policy: Coalesce, RetryInterval: 5 * time.Millisecond})
+	client := make(chan models.StreamEvent, 1)
+	distributor.AddClient(client, ports.ClientOptions{})
+	defer distributor.RemoveClient(client)
+
+	// Only the first event fits, the others are held back, keeping the latest update of R-2
+	distributor.Broadcast(vehicleUpdate(1, "R-1"))
+	distributor.Broadcast(vehicleUpdate(2, "R-2"))
+	distributor.Broadcast(vehicleUpdate(3, "R-3"))
+	distributor.Broadcast(vehicleUpdate(4, "R-2"))
+
+	// The held events are delivered as the client drains its channel, in order
+	want := []uint64{1, 4, 3}
+	for _, id := range want {
+		select {
+		case event := <-client:
+			if event.EventID() != id {
+				t.Errorf("got event %d, want %d", event.EventID(), id)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %d was never delivered", id)
+		}
+	}
+
+	select {
+	case event := <-client:
+		t.Errorf("unexpected event %d", event.EventID())
+	case <-time.After(20 * time.Millisecond):
+	}
+	if drops := distributor.DistributorStats().DroppedMessages; drops != 1 {
+		t.Errorf("dropped %d events, want the superseded update only", drops)
+	}
+}
+
+func TestThrottledClientReceivesMergedEventsOncePerInterval(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	distributor := NewClientDistributor(DefaultDistributorOptions())
+	client := make(chan models.StreamEvent, 10)
+	distributor.AddClient(client, ports.ClientOptions{FlushInterval: interval})
+	defer distributor.RemoveClient(client)
+
+	// Within one interval only the latest event per vehicle is kept, a remove replacing a pending update
+	distributor.Broadcast(vehicleUpdate(1, "R-1"))
+	distributor.Broadcast(vehicleUpdate(2, "R-2"))
+	distributor.Broadcast(vehicleUpdate(3, "R-1"))
+	distributor.Broadcast(models.VehicleRemoved{ID: 4, VehicleID: "R-2"})
+	if events := receiveAll(client); len(events) > 0 {
+		t.Fatalf("got events %v before the interval ended", eventIDs(events))
+	}
+
+	time.Sleep(interval)
+	events := receiveAll(client)
+	if ids := eventIDs(events); len(ids) != 2 || ids[0] != 3 || ids[1] != 4 {
+		t.Fatalf("got events %v, want the latest update of R-1 then the remove of R-2", ids)
+	}
+	if events[1].EventName() != models.RemovedEvent {
+		t.Errorf("R-2 got a %s event, want the remove", events[1].EventName())
+	}
+
+	// A vehicle updated continuously is sent at most once per interval
+	var received []time.Time
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range client {
+			received = append(received, time.Now())
+		}
+	}()
+	for id := range uint64(50) {
+		distributor.Broadcast(vehicleUpdate(10+id, "R-1"))
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(interval)
+	distributor.RemoveClient(client)
+	<-done
+
+	if len(received) == 0 || len(received) > 7 {
+		t.Fatalf("got %d events over 250ms, want at most one per %s", len(received), interval)
+	}
+	for i := 1; i < len(received); i++ {
+		if gap := received[i].Sub(received[i-1]); gap < interval/2 {
+			t.Errorf("events %d and %d arrived %s apart, want about %s", i-1, i, gap, interval)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Package gtfsrt converts live vehicles into GTFS-Realtime VehiclePositions feeds,
+// so the Explorer can be consumed by off-the-shelf GTFS-Realtime tools.
+package gtfsrt
+
+import (
+	"explorer/internal/core/domain/models"
+	"time"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// gtfsRealtimeVersion is the version of the GTFS-Realtime specification the feeds follow.
+const gtfsRealtimeVersion = "2.0"
+
+// NewVehiclePositionsFeed builds a full-dataset feed with one VehiclePosition entity per vehicle.
+//
+// Parameters:
+// - vehicles: The current vehicle set.
+// - at: The time the feed is created, used as the header timestamp.
+//
+// Returns:
+// - The GTFS-Realtime FeedMessage.
+func NewVehiclePositionsFeed(vehicles []models.Vehicle, at time.Time) *gtfs.FeedMessage {
+	feed := newFeed(gtfs.FeedHeader_FULL_DATASET, at)
+	for _, vehicle := range vehicles {
+		feed.Entity = append(feed.Entity, newVehicleEntity(vehicle))
+	}
+	return feed
+}
+
+// NewVehicleEventFeed builds the feed carrying a single stream event. A reset becomes a
+// full-dataset feed, while adds, updates and removes become differential feeds with a
+// single entity, removes being marked as deleted.
+//
+// Parameters:
+// - event: The vehicle event to convert.
+// - at: The time the feed is created, used as the header timestamp.
+//
+// Returns:
+// - The GTFS-Realtime FeedMessage, or nil if the event type is not supported.
+func NewVehicleEventFeed(event models.StreamEvent, at time.Time) *gtfs.FeedMessage {
+	switch e := event.(type) {
+	case models.VehicleReset:
+		return NewVehiclePositionsFeed(e.Vehicles, at)
+
+	case models.VehicleAdded:
+		feed := newFeed(gtfs.FeedHeader_DIFFERENTIAL, at)
+		feed.Entity = []*gtfs.FeedEntity{newVehicleEntity(e.Vehicle)}
+		return feed
+
+	case models.VehicleUpdated:
+		feed := newFeed(gtfs.FeedHeader_DIFFERENTIAL, at)
+		feed.Entity = []*gtfs.FeedEntity{newVehicleEntity(e.Vehicle)}
+		return feed
+
+	case models.VehicleRemoved:
+		feed := newFeed(gtfs.FeedHeader_DIFFERENTIAL, at)
+		feed.Entity = []*gtfs.FeedEntity{{Id: proto.String(e.VehicleID), IsDeleted: proto.Bool(true)}}
+		return feed
+	}
+	return nil
+}
+
+// Marshal encodes a feed in the binary Protocol Buffers wire format.
+func Marshal(feed *gtfs.FeedMessage) ([]byte, error) {
+	return proto.Marshal(feed)
+}
+
+// MarshalText encodes a feed in the human-readable Protocol Buffers text format, for debugging.
+func MarshalText(feed *gtfs.FeedMessage) ([]byte, error) {
+	return prototext.MarshalOptions{Multiline: true}.Marshal(feed)
+}
+
+// newFeed returns an empty feed with its header set.
+func newFeed(incrementality gtfs.FeedHeader_Incrementality, at time.Time) *gtfs.FeedMessage {
+	return &gtfs.FeedMessage{
+		Header: &gtfs.FeedHeader{
+			GtfsRealtimeVersion: proto.String(gtfsRealtimeVersion),
+			Incrementality:      incrementality.Enum(),
+			Timestamp:           proto.Uint64(uint64(at.Unix())),
+		},
+	}
+}
+
+// newVehicleEntity converts a vehicle into a feed entity identified by the vehicle ID.
+// Optional GTFS-Realtime fields are only set when the MBTA API provided a value.
+func newVehicleEntity(vehicle models.Vehicle) *gtfs.FeedEntity {
+	attributes := vehicle.Attributes
+
+	position := &gtfs.VehiclePosition{
+		Trip: &gtfs.TripDescriptor{
+			RouteId:     proto.String(vehicle.RouteID()),
+			DirectionId: proto.Uint32(uint32(attributes.DirectionID)),
+		},
+		Vehicle: &gtfs.VehicleDescriptor{
+			Id:    proto.String(vehicle.ID),
+			Label: proto.String(attributes.Label),
+		},
+		Position: &gtfs.Position{
+			Latitude:  proto.Float32(float32(attributes.Latitude)),
+			Longitude: proto.Float32(float32(attributes.Longitude)),
+			Bearing:   proto.Float32(float32(attributes.Bearing)),
+		},
+		CurrentStopSequence: proto.Uint32(uint32(attributes.CurrentStopSequence)),
+	}
+
+	if tripID := vehicle.TripID(); tripID != "" {
+		position.Trip.TripId = proto.String(tripID)
+	}
+	if stopID := vehicle.StopID(); stopID != "" {
+		position.StopId = proto.String(stopID)
+	}
+	if attributes.Speed > 0 {
+		position.Position.Speed = proto.Float32(float32(attributes.Speed))
+	}
+	if status, ok := gtfs.VehiclePosition_VehicleStopStatus_value[attributes.CurrentStatus]; ok {
+		position.CurrentStatus = gtfs.VehiclePosition_VehicleStopStatus(status).Enum()
+	}
+	if occupancy, ok := gtfs.VehiclePosition_OccupancyStatus_value[attributes.OccupancyStatus]; ok {
+		position.OccupancyStatus = gtfs.VehiclePosition_OccupancyStatus(occupancy).Enum()
+	}
+	if updatedAt, err := time.Parse(time.RFC3339, attributes.UpdatedAt); err == nil {
+		position.Timestamp = proto.Uint64(uint64(updatedAt.Unix()))
+	}
+
+	return &gtfs.FeedEntity{
+		Id:      proto.String(vehicle.ID),
+		Vehicle: position,
+	}
+}
@@ -0,0 +1,113 @@
+package gtfsrt
+
+import (
+	"explorer/internal/core/domain/models"
+	"testing"
+	"time"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"google.golang.org/protobuf/proto"
+)
+
+// redLineVehicle returns a Red Line vehicle serving a trip towards a stop
+func redLineVehicle() models.Vehicle {
+	return models.Vehicle{
+		ID:    "R-5463D2B1",
+		Route: "Red",
+		Attributes: models.VehicleAttributes{
+			Bearing:             135,
+			CurrentStatus:       "IN_TRANSIT_TO",
+			CurrentStopSequence: 130,
+			DirectionID:         1,
+			Label:               "1841",
+			Latitude:            42.39674,
+			Longitude:           -71.12182,
+			OccupancyStatus:     "MANY_SEATS_AVAILABLE",
+			Speed:               12.5,
+			UpdatedAt:           "2025-01-11T21:13:39-05:00",
+		},
+		Relationships: &models.VehicleRelations{
+			Trip: models.RouteRelation{Data: models.RouteData{ID: "67268866", Type: "trip"}},
+			Stop: models.RouteRelation{Data: models.RouteData{ID: "70061", Type: "stop"}},
+		},
+	}
+}
+
+// roundTrip encodes the feed in the binary format and decodes it again
+func roundTrip(t *testing.T, feed *gtfs.FeedMessage) *gtfs.FeedMessage {
+	t.Helper()
+	data, err := Marshal(feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &gtfs.FeedMessage{}
+	if err := proto.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("feed does not decode as a FeedMessage: %v", err)
+	}
+	return decoded
+}
+
+func TestVehiclePositionsFeedRoundTrip(t *testing.T) {
+	at := time.Date(2025, 1, 12, 2, 14, 0, 0, time.UTC)
+	parked := models.Vehicle{ID: "G-10040", Route: "Green-B", Attributes: models.VehicleAttributes{Label: "3840"}}
+	feed := roundTrip(t, NewVehiclePositionsFeed([]models.Vehicle{redLineVehicle(), parked}, at))
+
+	header := feed.GetHeader()
+	if header.GetGtfsRealtimeVersion() != "2.0" || header.GetIncrementality() != gtfs.FeedHeader_FULL_DATASET ||
+		header.GetTimestamp() != uint64(at.Unix()) {
+		t.Errorf("header = %v, want a full dataset of version 2.0 at %d", header, at.Unix())
+	}
+	if len(feed.GetEntity()) != 2 {
+		t.Fatalf("feed has %d entities, want 2", len(feed.GetEntity()))
+	}
+
+	entity := feed.GetEntity()[0]
+	position := entity.GetVehicle()
+	if entity.GetId() != "R-5463D2B1" || position.GetVehicle().GetId() != "R-5463D2B1" || position.GetVehicle().GetLabel() != "1841" {
+		t.Errorf("entity %q vehicle = %v, want R-5463D2B1 labelled 1841", entity.GetId(), position.GetVehicle())
+	}
+	if trip := position.GetTrip(); trip.GetTripId() != "67268866" || trip.GetRouteId() != "Red" || trip.GetDirectionId() != 1 {
+		t.Errorf("trip = %v, want trip 67268866 of Red in direction 1", trip)
+	}
+	if p := position.GetPosition(); p.GetLatitude() != float32(42.39674) || p.GetLongitude() != float32(-71.12182) ||
+		p.GetBearing() != 135 || p.GetSpeed() != 12.5 {
+		t.Errorf("position = %v, want the vehicle's coordinates, bearing and speed", p)
+	}
+	if position.GetStopId() != "70061" || position.GetCurrentStopSequence() != 130 ||
+		position.GetCurrentStatus() != gtfs.VehiclePosition_IN_TRANSIT_TO {
+		t.Errorf("stop = %q #%d %v, want in transit to 70061 #130", position.GetStopId(), position.GetCurrentStopSequence(), position.GetCurrentStatus())
+	}
+	if position.GetOccupancyStatus() != gtfs.VehiclePosition_MANY_SEATS_AVAILABLE {
+		t.Errorf("occupancy = %v, want MANY_SEATS_AVAILABLE", position.GetOccupancyStatus())
+	}
+	if updatedAt := time.Date(2025, 1, 12, 2, 13, 39, 0, time.UTC); position.GetTimestamp() != uint64(updatedAt.Unix()) {
+		t.Errorf("timestamp = %d, want %d", position.GetTimestamp(), updatedAt.Unix())
+	}
+
+	// Optional fields without a value from the MBTA API are left unset
+	parkedPosition := feed.GetEntity()[1].GetVehicle()
+	if parkedPosition.Trip.TripId != nil || parkedPosition.StopId != nil || parkedPosition.Position.Speed != nil ||
+		parkedPosition.CurrentStatus != nil || parkedPosition.Timestamp != nil {
+		t.Errorf("vehicle without a trip, stop, speed, status or update time = %v, want them unset", parkedPosition)
+	}
+}
+
+func TestVehicleEventFeedRoundTrip(t *testing.T) {
+	at := time.Now()
+
+	updated := roundTrip(t, NewVehicleEventFeed(models.VehicleUpdated{ID: 7, Vehicle: redLineVehicle()}, at))
+	if updated.GetHeader().GetIncrementality() != gtfs.FeedHeader_DIFFERENTIAL || len(updated.GetEntity()) != 1 ||
+		updated.GetEntity()[0].GetVehicle().GetTrip().GetRouteId() != "Red" {
+		t.Errorf("update feed = %v, want a differential feed with the Red Line vehicle", updated)
+	}
+
+	removed := roundTrip(t, NewVehicleEventFeed(models.VehicleRemoved{ID: 8, VehicleID: "R-5463D2B1"}, at))
+	if len(removed.GetEntity()) != 1 || removed.GetEntity()[0].GetId() != "R-5463D2B1" || !removed.GetEntity()[0].GetIsDeleted() ||
+		removed.GetEntity()[0].GetVehicle() != nil {
+		t.Errorf("remove feed = %v, want the vehicle marked as deleted", removed)
+	}
+
+	if feed := NewVehicleEventFeed(models.PredictionRemoved{PredictionID: "p"}, at); feed != nil {
+		t.Errorf("feed of a prediction event = %v, want none", feed)
+	}
+}
@@ -14,16 +14,34 @@ import (
 // Parameters:
 // - router: The Gorilla Mux router used to define the HTTP endpoints.
 // - mbtaApiHelper: Helper interface for interacting with the MBTA API.
-// - sm: StreamManagerUseCase responsible for managing vehicle streaming.
-func RegisterRoutes(router *mux.Router, mbtaApiHelper usecases.MbtaApiHelper, sm ports.StreamManager) {
+// - registry: StreamRegistry providing the shared stream for each upstream query.
+func RegisterRoutes(router *mux.Router, mbtaApiHelper usecases.MbtaApiHelper, registry ports.StreamRegistry) {
 
 	// Initialize handlers for each route
-	streamVehiclesHandler := handlers.NewStreamVehiclesHandler(sm)                                       // Handles streaming of vehicle data
-	vehiclePositionHandler := middleware.CompressHandler(handlers.VehiclePositionHandler(mbtaApiHelper)) // Handles live vehicle positions
+	streamVehiclesHandler := handlers.NewStreamVehiclesHandler(registry)                                           // Handles streaming of vehicle data
+	streamVehiclesWSHandler := handlers.NewStreamVehiclesWSHandler(registry)                                       // Handles streaming of vehicle data over WebSocket
+	vehiclePositionHandler := middleware.CompressHandler(handlers.VehiclePositionHandler(mbtaApiHelper, registry)) // Handles live vehicle positions
 	routesHandler := middleware.CompressHandler(handlers.RouteHandler(mbtaApiHelper))
+	routeCatalogHandler := middleware.CompressHandler(handlers.RouteCatalogHandler(mbtaApiHelper)) // Lists every route with its names, colors and line
+	predictionsHandler := middleware.CompressHandler(handlers.PredictionsHandler(mbtaApiHelper))   // Handles arrival and departure predictions
+	streamPredictionsHandler := handlers.NewStreamPredictionsHandler(registry)                     // Handles streaming of predictions
+	tripHandler := middleware.CompressHandler(handlers.TripHandler(mbtaApiHelper))                 // Handles trip details
+	schedulesHandler := middleware.CompressHandler(handlers.SchedulesHandler(mbtaApiHelper))       // Handles scheduled arrival and departure times
+	alertsHandler := middleware.CompressHandler(handlers.AlertsHandler(mbtaApiHelper))             // Handles service alerts
+	streamAlertsHandler := handlers.NewStreamAlertsHandler(registry)                               // Handles streaming of service alerts
+	streamStatusHandler := handlers.StreamStatusHandler(registry)                                  // Reports upstream stream health
 
 	// Define HTTP endpoints and their corresponding handlers
-	router.Handle("/stream/vehicles", streamVehiclesHandler)              // Streaming endpoint for vehicle data
-	router.Handle("/api/routes", routesHandler).Methods("GET")            // Fetch route list via GET
-	router.Handle("/api/vehicles", vehiclePositionHandler).Methods("GET") // Fetch live vehicle positions via GET
+	router.Handle("/stream/vehicles", streamVehiclesHandler)                 // Streaming endpoint for vehicle data
+	router.Handle("/stream/vehicles/ws", streamVehiclesWSHandler)            // WebSocket streaming endpoint for vehicle data
+	router.Handle("/api/routes", routesHandler).Methods("GET")               // Fetch route list via GET
+	router.Handle("/api/routes/catalog", routeCatalogHandler).Methods("GET") // Fetch the route catalog via GET
+	router.Handle("/api/vehicles", vehiclePositionHandler).Methods("GET")    // Fetch live vehicle positions via GET
+	router.Handle("/stream/predictions", streamPredictionsHandler)           // Streaming endpoint for predictions
+	router.Handle("/api/predictions", predictionsHandler).Methods("GET")     // Fetch predictions via GET
+	router.Handle("/api/trips/{id}", tripHandler).Methods("GET")             // Fetch a trip with its stops, vehicle and shape via GET
+	router.Handle("/api/schedules", schedulesHandler).Methods("GET")         // Fetch schedules via GET
+	router.Handle("/stream/alerts", streamAlertsHandler)                     // Streaming endpoint for service alerts
+	router.Handle("/api/alerts", alertsHandler).Methods("GET")               // Fetch service alerts via GET
+	router.Handle("/api/stream/status", streamStatusHandler).Methods("GET")  // Fetch upstream stream health via GET
 }
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"explorer/internal/core/domain/models"
+	"explorer/internal/pkg"
+	"fmt"
+)
+
+// encodeAlertEvent serializes the payload of an alert event as JSON: a list of alerts for a
+// reset, a single alert for an add or update, and a resource identifier for a remove.
+func encodeAlertEvent(event models.StreamEvent) ([]byte, error) {
+	switch e := event.(type) {
+	case models.AlertReset:
+		if e.Alerts == nil {
+			e.Alerts = []models.Alert{} // Encode an empty reset as [] rather than null
+		}
+		return json.Marshal(e.Alerts)
+	case models.AlertAdded:
+		return json.Marshal(e.Alert)
+	case models.AlertUpdated:
+		return json.Marshal(e.Alert)
+	case models.AlertRemoved:
+		return json.Marshal(models.RouteData{ID: e.AlertID, Type: "alert"})
+	}
+	return nil, fmt.Errorf("unsupported alert event %T", event)
+}
+
+// formatSSEAlertEvent serializes an alert event as an SSE message.
+func formatSSEAlertEvent(event models.StreamEvent) (string, error) {
+	data, err := encodeAlertEvent(event)
+	if err != nil {
+		return "", err
+	}
+	return pkg.FormatSSE(pkg.SSEEvent{ID: formatEventID(event), Event: event.EventName(), Data: string(data)}), nil
+}
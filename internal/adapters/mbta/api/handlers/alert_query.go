@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"explorer/internal/core/domain/models"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// alertActivities lists the activities the MBTA API can filter alerts by. ALL matches alerts affecting any activity.
+var alertActivities = []string{"ALL", "BOARD", "BRINGING_BIKE", "EXIT", "PARK_CAR", "RIDE", "STORE_BIKE", "USING_ESCALATOR", "USING_WHEELCHAIR"}
+
+// parseAlertQuery reads the filters selecting the alerts to fetch or stream.
+//
+// Supported parameters:
+// - route_ids: Comma-separated list of route IDs (e.g., ?route_ids=Red,Orange).
+// - stop_id: Comma-separated list of stop IDs (e.g., ?stop_id=place-pktrm).
+// - activity: Comma-separated list of activities (e.g., ?activity=USING_WHEELCHAIR for elevator outages).
+// - severity: Comma-separated list of severities from 0 to 10 (e.g., ?severity=7,8,9,10).
+//
+// Returns:
+// - The alert filter, or an error if an activity or severity is invalid.
+func parseAlertQuery(r *http.Request) (models.AlertFilter, error) {
+	filter := models.AlertFilter{
+		RouteIDs: parseIDs(r, "route_ids"),
+		StopIDs:  parseIDs(r, "stop_id"),
+	}
+
+	for _, activity := range parseIDs(r, "activity") {
+		activity = strings.ToUpper(activity)
+		if !slices.Contains(alertActivities, activity) {
+			return models.AlertFilter{}, fmt.Errorf("invalid activity %q, expected one of %s", activity, strings.Join(alertActivities, ", "))
+		}
+		filter.Activities = append(filter.Activities, activity)
+	}
+
+	for _, strSeverity := range parseIDs(r, "severity") {
+		severity, err := strconv.Atoi(strSeverity)
+		if err != nil || severity < 0 || severity > 10 {
+			return models.AlertFilter{}, fmt.Errorf("invalid severity %q, expected 0 to 10", strSeverity)
+		}
+		filter.Severities = append(filter.Severities, severity)
+	}
+
+	return filter, nil
+}
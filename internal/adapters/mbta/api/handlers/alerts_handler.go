@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/json"
+	"explorer/internal/core/usecases"
+	"log"
+	"net/http"
+)
+
+// AlertsHandler is an HTTP handler function that returns the service alerts in effect matching the
+// request query parameters, most severe first, with the routes and stops each alert affects.
+func AlertsHandler(useCases usecases.MbtaApiHelper) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Extract the filters (e.g., /api/alerts?route_ids=Red&activity=USING_WHEELCHAIR)
+		filter, err := parseAlertQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Fetch the alerts, which are never cached
+		alerts, err := useCases.GetAlerts(r.Context(), filter)
+		if err != nil {
+			log.Println("Error fetching alerts:", err)
+			http.Error(w, "Error fetching alerts", http.StatusInternalServerError)
+			return
+		}
+
+		// Set the response header to specify that the content being returned is in JSON format
+		w.Header().Set("Content-Type", "application/json")
+
+		// Encode the alerts as JSON and send them in the response body
+		json.NewEncoder(w).Encode(alerts)
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"explorer/internal/core/domain/models"
+	"explorer/internal/pkg"
+	"fmt"
+)
+
+// encodePredictionEvent serializes the payload of a prediction event as JSON: a list of
+// predictions for a reset, a single prediction for an add or update, and a resource
+// identifier for a remove.
+func encodePredictionEvent(event models.StreamEvent) ([]byte, error) {
+	switch e := event.(type) {
+	case models.PredictionReset:
+		if e.Predictions == nil {
+			e.Predictions = []models.Prediction{} // Encode an empty reset as [] rather than null
+		}
+		return json.Marshal(e.Predictions)
+	case models.PredictionAdded:
+		return json.Marshal(e.Prediction)
+	case models.PredictionUpdated:
+		return json.Marshal(e.Prediction)
+	case models.PredictionRemoved:
+		return json.Marshal(models.RouteData{ID: e.PredictionID, Type: "prediction"})
+	}
+	return nil, fmt.Errorf("unsupported prediction event %T", event)
+}
+
+// formatSSEPredictionEvent serializes a prediction event as an SSE message.
+func formatSSEPredictionEvent(event models.StreamEvent) (string, error) {
+	data, err := encodePredictionEvent(event)
+	if err != nil {
+		return "", err
+	}
+	return pkg.FormatSSE(pkg.SSEEvent{ID: formatEventID(event), Event: event.EventName(), Data: string(data)}), nil
+}
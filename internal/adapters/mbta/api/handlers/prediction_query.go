@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// parsePredictionQuery reads the stops and routes to fetch predictions for.
+//
+// Supported parameters:
+// - stop_id: Comma-separated list of stop IDs (e.g., ?stop_id=place-pktrm).
+// - route_ids: Comma-separated list of route IDs (e.g., ?route_ids=Red,Orange).
+//
+// Returns:
+// - The stop IDs and route IDs, or an error if neither is set, as the MBTA API requires at least one.
+func parsePredictionQuery(r *http.Request) (stopIDs, routeIDs []string, err error) {
+	stopIDs = parseIDs(r, "stop_id")
+	routeIDs = parseIDs(r, "route_ids")
+	if stopIDs == nil && routeIDs == nil {
+		return nil, nil, fmt.Errorf("stop_id or route_ids is required")
+	}
+	return stopIDs, routeIDs, nil
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"explorer/internal/core/usecases"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// PredictionsHandler is an HTTP handler function that returns the predicted arrival and departure
+// times at the stops and/or on the routes given in the request query parameters, soonest first.
+func PredictionsHandler(useCases usecases.MbtaApiHelper) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Extract the stops and routes (e.g., /api/predictions?stop_id=place-pktrm&route_ids=Red)
+		stopIDs, routeIDs, err := parsePredictionQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Fetch the predictions, which are never cached
+		predictions, err := useCases.GetPredictions(r.Context(), strings.Join(stopIDs, ","), strings.Join(routeIDs, ","))
+		if err != nil {
+			log.Println("Error fetching predictions:", err)
+			http.Error(w, "Error fetching predictions", http.StatusInternalServerError)
+			return
+		}
+
+		// Set the response header to specify that the content being returned is in JSON format
+		w.Header().Set("Content-Type", "application/json")
+
+		// Encode the predictions as JSON and send them in the response body
+		json.NewEncoder(w).Encode(predictions)
+	}
+}
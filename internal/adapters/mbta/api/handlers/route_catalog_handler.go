@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"explorer/internal/core/usecases"
+	"log"
+	"net/http"
+)
+
+// RouteCatalogHandler is an HTTP handler function that lists the MBTA routes with their names,
+// colors, type, directions and parent line, so clients do not need to hardcode route IDs.
+// The list can be restricted to the route types in the optional route_type query parameter.
+func RouteCatalogHandler(useCases usecases.MbtaApiHelper) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Extract the optional route types (e.g., /api/routes/catalog?route_type=0,1 for rapid transit)
+		routeTypes, err := parseRouteTypes(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Fetch the routes, served from the cache when possible
+		routes, err := useCases.GetRoutes(r.Context(), routeTypes)
+		if err != nil {
+			log.Println("Error fetching route catalog:", err)
+			http.Error(w, "Error fetching routes", http.StatusInternalServerError)
+			return
+		}
+
+		// Set the response header to specify that the content being returned is in JSON format
+		w.Header().Set("Content-Type", "application/json")
+
+		// Encode the routes as JSON and send them in the response body
+		json.NewEncoder(w).Encode(routes)
+	}
+}
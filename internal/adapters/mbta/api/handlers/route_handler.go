@@ -1,60 +1,90 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"                                // Import the json package for JSON encoding/decoding
 	"explorer/internal/adapters/mbta/api/response" // Import response models for structured API responses
+	"explorer/internal/core/domain/models"
 	"explorer/internal/core/usecases"
 	"log"      // Import the log package for logging errors and information
 	"net/http" // Import net/http for building HTTP handlers
-	"strings"  // Import strings package to handle string operations like splitting
+	"slices"
+	"strings" // Import strings package to handle string operations like joining
+	"sync"
+	"time"
 )
 
+// routeFetchConcurrency is the most stop and shape lookups in flight at once for a request
+const routeFetchConcurrency = 8
+
+// routeFetchTimeout is the longest a request waits for its routes, unless the client gives up first.
+// It is a variable so tests can shorten it.
+var routeFetchTimeout = 15 * time.Second
+
 // RouteHandler is an HTTP handler function that returns all relevant data (stops and shapes)
-// for a list of route IDs provided in the request query parameters.
+// for a list of route IDs provided in the request query parameters, optionally restricted to
+// (or, without route IDs, listing every route of) the route types in the route_type parameter.
+// The stops and shapes of every route are fetched concurrently. A route that cannot be fetched
+// before the deadline carries an error instead of failing the whole response. Without route IDs
+// or route types it responds with 400 Bad Request.
 func RouteHandler(useCases usecases.MbtaApiHelper) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
-		// Extract the route IDs from the query parameter (e.g., /routes?route_ids=Red,Orange,Blue)
-		strRouteIDs := r.URL.Query().Get("route_ids")
-
-		// Split the comma-separated route IDs into a slice of strings
-		routeIDs := strings.Split(strRouteIDs, ",")
+		// Extract the route IDs from the query parameter (e.g., /routes?route_ids=Red,Orange,Blue), skipping empty IDs
+		routeIDs := parseIDs(r, "route_ids")
 
-		// Initialize a slice to hold the aggregated responses for each route
-		var responses []response.GetRouteResponse
+		// Extract the optional route types (e.g., ?route_type=2 for commuter rail)
+		routeTypes, err := parseRouteTypes(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if routeIDs == nil && routeTypes == nil {
+			http.Error(w, "route_ids or route_type is required", http.StatusBadRequest)
+			return
+		}
 
-		// Iterate through each route ID to fetch stops and shapes
-		for _, routeID := range routeIDs {
-			// Fetch stops for the current route ID
-			stops, err := useCases.GetStops(routeID)
-			if err != nil {
-				log.Printf("Error fetching stops for route %s: %v", routeID, err)
-				http.Error(w, "Error fetching stops for one or more routes", http.StatusInternalServerError)
-				return
-			}
+		// Stop fetching the routes at the deadline or when the client goes away
+		ctx, cancel := context.WithTimeout(r.Context(), routeFetchTimeout)
+		defer cancel()
 
-			// Fetch shapes for the current route ID
-			shapes, err := useCases.GetShapes(routeID)
+		// Restrict the routes to the requested route types, or list every route of those types if no route IDs were given
+		if routeTypes != nil {
+			routeIDs, err = routeIDsOfTypes(ctx, useCases, routeIDs, routeIDs == nil, routeTypes)
 			if err != nil {
-				log.Printf("Error fetching shapes for route %s: %v", routeID, err)
-				http.Error(w, "Error fetching shapes for one or more routes", http.StatusInternalServerError)
+				log.Printf("Error fetching routes of types %v: %v", routeTypes, err)
+				http.Error(w, "Error fetching routes", http.StatusInternalServerError)
 				return
 			}
+		}
 
-			// Construct the response for the current route
-			routeResponse := response.GetRouteResponse{
-				ID:          routeID,            // Set the route ID
-				Coordinates: shapes.Coordinates, // Assign the decoded shapes (coordinates)
-				Stops:       stops,              // Assign the fetched stops
-			}
-
-			// Add the constructed response to the list
-			responses = append(responses, routeResponse)
+		// Fetch the routes concurrently, sharing a limit on the lookups in flight, in the requested order
+		responses := make([]response.GetRouteResponse, len(routeIDs))
+		slots := make(chan struct{}, routeFetchConcurrency)
+		var wg sync.WaitGroup
+		for i, routeID := range routeIDs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				responses[i] = fetchRoute(ctx, useCases, slots, routeID)
+			}()
 		}
+		wg.Wait()
 
 		// Set the Content-Type header to indicate JSON response
 		w.Header().Set("Content-Type", "application/json")
 
+		// Fail only if no route could be fetched, the errors are listed per route either way
+		failed := 0
+		for _, routeResponse := range responses {
+			if routeResponse.Error != "" {
+				failed++
+			}
+		}
+		if failed > 0 && failed == len(responses) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
 		// Encode the aggregated responses as JSON and send them in the response body
 		if err := json.NewEncoder(w).Encode(responses); err != nil {
 			log.Printf("Error encoding response: %v", err)
@@ -62,3 +92,100 @@ func RouteHandler(useCases usecases.MbtaApiHelper) http.HandlerFunc {
 		}
 	}
 }
+
+// fetchRoute fetches the stops and shapes of a route concurrently, each waiting for one of the slots.
+// If either cannot be fetched before the context is done, the route carries an error and whatever
+// was fetched.
+func fetchRoute(ctx context.Context, useCases usecases.MbtaApiHelper, slots chan struct{}, routeID string) response.GetRouteResponse {
+	var (
+		stops     []models.Stop
+		shapes    models.DecodedRouteShape
+		stopsErr  error
+		shapesErr error
+		wg        sync.WaitGroup
+	)
+
+	// Both lookups give up as soon as the context is done
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		stopsErr = withSlot(ctx, slots, func() (err error) {
+			stops, err = useCases.GetStops(ctx, routeID)
+			return err
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		shapesErr = withSlot(ctx, slots, func() (err error) {
+			shapes, err = useCases.GetShapes(ctx, routeID)
+			return err
+		})
+	}()
+	wg.Wait()
+
+	// Construct the response for the route, with empty stops and coordinates until they are fetched
+	routeResponse := response.GetRouteResponse{
+		ID:          routeID,
+		Coordinates: [][][]float64{},
+		Stops:       []models.Stop{},
+	}
+
+	var errs []string
+	if stopsErr != nil {
+		log.Printf("Error fetching stops for route %s: %v", routeID, stopsErr)
+		errs = append(errs, routeFetchError(ctx, "stops"))
+	} else {
+		routeResponse.Stops = stops
+	}
+	if shapesErr != nil {
+		log.Printf("Error fetching shapes for route %s: %v", routeID, shapesErr)
+		errs = append(errs, routeFetchError(ctx, "shapes"))
+	} else {
+		routeResponse.Coordinates = shapes.Coordinates
+	}
+	routeResponse.Error = strings.Join(errs, "; ")
+
+	// Describe the mode of the route, if known
+	if routeType, ok := useCases.RouteType(ctx, routeID); ok {
+		routeResponse.Type = &routeType
+		routeResponse.Mode = models.RouteTypeMode(routeType)
+	}
+
+	return routeResponse
+}
+
+// routeFetchError describes the failure to fetch part of a route, telling a lookup cut short by the deadline apart
+func routeFetchError(ctx context.Context, part string) string {
+	if ctx.Err() != nil {
+		return "Timed out fetching " + part
+	}
+	return "Error fetching " + part
+}
+
+// withSlot runs fetch once one of the slots is free, holding it until fetch returns.
+// It returns the context's error without running fetch if the context is done first.
+func withSlot(ctx context.Context, slots chan struct{}, fetch func() error) error {
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-slots }()
+	return fetch()
+}
+
+// routeIDsOfTypes returns the route IDs of the given route types. If all is set, every route
+// of those types is returned, otherwise only the given route IDs that are of those types.
+func routeIDsOfTypes(ctx context.Context, useCases usecases.MbtaApiHelper, routeIDs []string, all bool, routeTypes []int) ([]string, error) {
+	if all {
+		return useCases.GetRouteIDs(ctx, routeTypes)
+	}
+
+	var matching []string
+	for _, routeID := range routeIDs {
+		if routeType, ok := useCases.RouteType(ctx, routeID); ok && slices.Contains(routeTypes, routeType) {
+			matching = append(matching, routeID)
+		}
+	}
+	return matching, nil
+}
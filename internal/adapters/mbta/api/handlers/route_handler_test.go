@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"explorer/internal/adapters/mbta/api/response"
+	"explorer/internal/core/domain/models"
+	"explorer/internal/core/usecases"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// routesUseCases serves the stops and shapes of routes: "broken" always fails, "slow" never answers
+// before its context is done, and every other route is found
+type routesUseCases struct {
+	usecases.MbtaApiHelper // Other calls are not used
+}
+
+func (routesUseCases) fetch(ctx context.Context, routeID string) error {
+	switch routeID {
+	case "broken":
+		return errors.New("MBTA API unavailable")
+	case "slow":
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (u routesUseCases) GetStops(ctx context.Context, routeID string) ([]models.Stop, error) {
+	if err := u.fetch(ctx, routeID); err != nil {
+		return nil, err
+	}
+	return []models.Stop{{ID: routeID + "-stop"}}, nil
+}
+
+func (u routesUseCases) GetShapes(ctx context.Context, routeID string) (models.DecodedRouteShape, error) {
+	if err := u.fetch(ctx, routeID); err != nil {
+		return models.DecodedRouteShape{}, err
+	}
+	return models.DecodedRouteShape{Coordinates: [][][]float64{{{42.39674, -71.12182}}}}, nil
+}
+
+func (routesUseCases) RouteType(ctx context.Context, routeID string) (int, bool) {
+	return models.RouteTypeSubway, routeID == "Red"
+}
+
+// getRoutes serves the query with RouteHandler, returning the status and the decoded routes
+func getRoutes(t *testing.T, query string) (int, []response.GetRouteResponse) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	RouteHandler(routesUseCases{})(recorder, httptest.NewRequest(http.MethodGet, "/api/routes?"+query, nil))
+	if recorder.Code == http.StatusBadRequest {
+		return recorder.Code, nil
+	}
+
+	var routes []response.GetRouteResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &routes); err != nil {
+		t.Fatalf("response is not a list of routes: %s", recorder.Body)
+	}
+	return recorder.Code, routes
+}
+
+func TestRouteHandlerRequiresRoutes(t *testing.T) {
+	for _, query := range []string{"", "route_ids=", "route_ids=,", "route_ids=%20"} {
+		if status, _ := getRoutes(t, query); status != http.StatusBadRequest {
+			t.Errorf("%q: status %d, want %d", query, status, http.StatusBadRequest)
+		}
+	}
+
+	// Empty IDs are dropped rather than fetched
+	if status, routes := getRoutes(t, "route_ids=Red,,"); status != http.StatusOK || len(routes) != 1 || routes[0].ID != "Red" {
+		t.Errorf("status %d, routes %+v, want Red only", status, routes)
+	}
+
+	// No route of the requested types is an empty list
+	if status, routes := getRoutes(t, "route_ids=Orange&route_type=3"); status != http.StatusOK || routes == nil || len(routes) != 0 {
+		t.Errorf("status %d, routes %+v, want an empty list", status, routes)
+	}
+}
+
+func TestRouteHandlerPartialFailures(t *testing.T) {
+	timeout := routeFetchTimeout
+	routeFetchTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { routeFetchTimeout = timeout })
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		errors map[string]string // Error of each route, empty for routes fetched in full
+	}{
+		{
+			name:   "one route failing",
+			query:  "route_ids=Red,broken",
+			status: http.StatusOK,
+			errors: map[string]string{"Red": "", "broken": "Error fetching stops; Error fetching shapes"},
+		},
+		{
+			name:   "one route timing out",
+			query:  "route_ids=slow,Red",
+			status: http.StatusOK,
+			errors: map[string]string{"slow": "Timed out fetching stops; Timed out fetching shapes", "Red": ""},
+		},
+		{
+			name:   "every route failing",
+			query:  "route_ids=broken,slow",
+			status: http.StatusInternalServerError,
+			errors: map[string]string{
+				"broken": "Error fetching stops; Error fetching shapes",
+				"slow":   "Timed out fetching stops; Timed out fetching shapes",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			status, routes := getRoutes(t, tt.query)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("responded after %s, want the deadline to cut the request short", elapsed)
+			}
+			if status != tt.status {
+				t.Errorf("status %d, want %d", status, tt.status)
+			}
+			if len(routes) != len(tt.errors) {
+				t.Fatalf("got %d routes, want %d", len(routes), len(tt.errors))
+			}
+			for _, route := range routes {
+				if route.Error != tt.errors[route.ID] {
+					t.Errorf("route %s error = %q, want %q", route.ID, route.Error, tt.errors[route.ID])
+				}
+				if fetched := len(route.Stops) > 0 && len(route.Coordinates) > 0; fetched != (route.Error == "") {
+					t.Errorf("route %s has %d stops and %d shapes with error %q", route.ID, len(route.Stops), len(route.Coordinates), route.Error)
+				}
+				if route.Stops == nil || route.Coordinates == nil {
+					t.Errorf("route %s stops or coordinates are null, want empty lists", route.ID)
+				}
+			}
+		})
+	}
+}
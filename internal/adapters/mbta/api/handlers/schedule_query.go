@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"explorer/internal/core/domain/models"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseScheduleQuery reads the filters selecting the schedules to fetch.
+//
+// Supported parameters:
+// - route_id: Comma-separated list of route IDs (e.g., ?route_id=Red).
+// - stop_id: Comma-separated list of stop IDs (e.g., ?stop_id=place-harsq).
+// - date: Service date as YYYY-MM-DD (e.g., ?date=2025-01-13), today's service date if not set.
+// - direction: Direction ID, 0 or 1 (e.g., ?direction=0), both directions if not set.
+//
+// Returns:
+// - The schedule filter, or an error if neither route_id nor stop_id is set, as the MBTA API requires
+// at least one, or if the date or direction is invalid.
+func parseScheduleQuery(r *http.Request) (models.ScheduleFilter, error) {
+	query := r.URL.Query()
+	filter := models.ScheduleFilter{
+		RouteID: strings.Join(parseIDs(r, "route_id"), ","),
+		StopID:  strings.Join(parseIDs(r, "stop_id"), ","),
+	}
+	if filter.RouteID == "" && filter.StopID == "" {
+		return models.ScheduleFilter{}, fmt.Errorf("route_id or stop_id is required")
+	}
+
+	if date := query.Get("date"); date != "" {
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return models.ScheduleFilter{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date)
+		}
+		filter.Date = date
+	}
+
+	if strDirection := query.Get("direction"); strDirection != "" {
+		direction, err := strconv.Atoi(strDirection)
+		if err != nil || (direction != 0 && direction != 1) {
+			return models.ScheduleFilter{}, fmt.Errorf("invalid direction %q, expected 0 or 1", strDirection)
+		}
+		filter.DirectionID = &direction
+	}
+
+	return filter, nil
+}
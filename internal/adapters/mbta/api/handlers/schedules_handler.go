@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"explorer/internal/core/usecases"
+	"log"
+	"net/http"
+)
+
+// SchedulesHandler is an HTTP handler function that returns the scheduled arrival and departure times,
+// with trip headsigns, at the stops and/or on the routes given in the request query parameters for a
+// service date, earliest first.
+func SchedulesHandler(useCases usecases.MbtaApiHelper) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Extract the filters (e.g., /api/schedules?route_id=Red&stop_id=place-harsq&date=2025-01-13&direction=0)
+		filter, err := parseScheduleQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Fetch the schedules, cached per service date
+		schedules, err := useCases.GetSchedules(r.Context(), filter)
+		if err != nil {
+			log.Println("Error fetching schedules:", err)
+			http.Error(w, "Error fetching schedules", http.StatusInternalServerError)
+			return
+		}
+
+		// Set the response header to specify that the content being returned is in JSON format
+		w.Header().Set("Content-Type", "application/json")
+
+		// Encode the schedules as JSON and send them in the response body
+		json.NewEncoder(w).Encode(schedules)
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"explorer/internal/core/domain/models"
+	"explorer/internal/infrastructure/config"
+	ports "explorer/internal/ports/streaming"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// sseRetryDelay is the reconnection delay suggested to browsers with the retry field
+const sseRetryDelay = 3 * time.Second
+
+// sseHeartbeatInterval is the time without events after which a keep-alive comment is sent.
+// It is a variable so tests can shorten it.
+var sseHeartbeatInterval = 15 * time.Second
+
+// sseStreamUseCase is implemented by the use case of every stream served over SSE.
+type sseStreamUseCase interface {
+	StreamSetup(url, apiKey string, opts ports.ClientOptions) chan models.StreamEvent
+	HandleDisconnect(ctx context.Context, clientChan chan models.StreamEvent)
+}
+
+// serveSSE streams the events of a shared upstream stream to the client as SSE messages, until
+// the client is removed or the connection is closed. Stream handlers call it once the query is parsed.
+//
+// Parameters:
+// - w: The HTTP response writer the stream is written to.
+// - r: The HTTP request object, whose context ends the stream.
+// - registry: The StreamRegistry providing the shared stream for url.
+// - url: The upstream stream URL serving the client's query.
+// - newUseCase: Builds the use case of the stream from its manager.
+// - opts: The options of the client. Its LastEventID is read from the request.
+// - format: Serializes an event as an SSE message, returning an empty string to skip it.
+//
+// Functionality:
+// - Fails with a 500 status if the writer cannot flush each event as it is written.
+// - Holds the stream from the registry until the client is removed.
+// - Sets up the SSE headers, disables proxy buffering and sends a retry hint.
+// - Resumes from the Last-Event-ID header sent by reconnecting browsers, if any.
+// - Writes every event of the client, with keep-alive comments when no data has been sent for a while.
+func serveSSE[U sseStreamUseCase](w http.ResponseWriter, r *http.Request, registry ports.StreamRegistry, url string,
+	newUseCase func(ports.StreamManager) U, opts ports.ClientOptions, format func(models.StreamEvent) (string, error)) {
+	// Streaming requires flushing each event as it is written; fail cleanly if the writer cannot.
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		log.Printf("Streaming unsupported: %T does not implement http.Flusher", w)
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Get the shared upstream stream, held until the client has been added.
+	streamManager, release := registry.Acquire(url)
+	defer release() // Let the registry discard the stream once it is idle
+	useCase := newUseCase(streamManager)
+
+	// Set up the SSE headers and send the retry hint.
+	startSSE(w, flusher)
+
+	// Initialize the stream and obtain a dedicated channel for this client.
+	opts.LastEventID = parseLastEventID(r) // Resume point after a reconnect
+	clientChan := useCase.StreamSetup(url, config.GetAPIKey(), opts)
+	defer streamManager.RemoveClient(clientChan) // Ensure client is removed when function exits.
+
+	// Handle client disconnection to prevent resource leaks.
+	useCase.HandleDisconnect(r.Context(), clientChan)
+
+	// Stream the events to the client as they arrive.
+	writeSSE(w, r, flusher, clientChan, format)
+}
+
+// parseLastEventID reads the Last-Event-ID header sent by browsers when an EventSource reconnects.
+// It returns 0 if the header is missing or is not an event ID issued by this server.
+func parseLastEventID(r *http.Request) uint64 {
+	id, err := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// startSSE sets up the SSE headers, disables proxy buffering and sends a retry hint.
+//
+// Parameters:
+// - w: The HTTP response writer the stream is written to.
+// - flusher: The flusher of w, used to send the headers right away.
+func startSSE(w http.ResponseWriter, flusher http.Flusher) {
+	// Set SSE-specific headers to enable a persistent connection for streaming data.
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Ask nginx not to buffer the stream
+
+	// Tell the browser how long to wait before reconnecting, and send the headers right away
+	// so proxies see the response start even if no data is available yet.
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetryDelay.Milliseconds())
+	flusher.Flush()
+}
+
+// writeSSE sends the events of a client channel as SSE messages until the client is removed
+// or the connection is closed.
+//
+// Parameters:
+// - w: The HTTP response writer the stream is written to.
+// - r: The HTTP request object, whose context ends the stream.
+// - flusher: The flusher of w, used to send each event as soon as it is written.
+// - clientChan: The channel registered with the stream distributor.
+// - format: Serializes an event as an SSE message, returning an empty string to skip it.
+//
+// Functionality:
+// - Writes and flushes every event, skipping (and logging) events that cannot be serialized.
+// - Sends keep-alive comments when no data has been sent for a while, so that proxies and
+// load balancers do not close the connection while nothing changes.
+func writeSSE(w http.ResponseWriter, r *http.Request, flusher http.Flusher, clientChan chan models.StreamEvent, format func(models.StreamEvent) (string, error)) {
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-clientChan:
+			if !ok {
+				return // The client was removed from the distributor
+			}
+
+			// Serialize the event for the SSE transport
+			data, err := format(event)
+			if err != nil {
+				log.Printf("Failed to encode %s event: %v", event.EventName(), err)
+				continue
+			}
+			if data == "" {
+				continue // Nothing to send for this client
+			}
+
+			if _, err := io.WriteString(w, data); err != nil {
+				return // The client went away
+			}
+			flusher.Flush() // Ensure data is immediately sent
+			heartbeat.Reset(sseHeartbeatInterval)
+
+		case <-heartbeat.C:
+			// SSE comment lines are ignored by clients but keep the connection active.
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"explorer/internal/adapters/distribute"
+	"explorer/internal/adapters/mbta/fake"
+	mbta "explorer/internal/adapters/mbta/stream"
+	"explorer/internal/adapters/store"
+	"explorer/internal/core/usecases"
+	ports "explorer/internal/ports/streaming"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newStreamTestServer serves the stream handler built by newHandler, fed by the fake MBTA API
+func newStreamTestServer(t *testing.T, newHandler func(ports.StreamRegistry) http.Handler) *httptest.Server {
+	t.Helper()
+	fakeAPI, err := fake.NewServer(time.Hour) // No vehicle moves during the test
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream := httptest.NewServer(fakeAPI)
+	t.Cleanup(upstream.Close)
+	t.Setenv("MBTA_API_BASE_URL", upstream.URL)
+
+	registry := usecases.NewStreamRegistryUseCase(func() (ports.StreamSource, ports.StreamDistributor, ports.StreamStore) {
+		distributor := distribute.NewClientDistributor(distribute.DefaultDistributorOptions())
+		streamStore := store.NewStreamStore()
+		return mbta.NewMBTAStreamSource(distributor, streamStore, mbta.DefaultSourceOptions()), distributor, streamStore
+	}, time.Second)
+	t.Cleanup(registry.Shutdown)
+
+	server := httptest.NewServer(newHandler(registry))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestServeSSESendsRetryHintAndHeartbeats(t *testing.T) {
+	heartbeatInterval := sseHeartbeatInterval
+	sseHeartbeatInterval = 20 * time.Millisecond
+	t.Cleanup(func() { sseHeartbeatInterval = heartbeatInterval })
+
+	server := newStreamTestServer(t, func(registry ports.StreamRegistry) http.Handler {
+		return NewStreamVehiclesHandler(registry)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?route_ids=Red", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", contentType)
+	}
+	if buffering := resp.Header.Get("X-Accel-Buffering"); buffering != "no" {
+		t.Errorf("X-Accel-Buffering = %q, want no", buffering)
+	}
+
+	// The stream starts with the retry hint, then the reset, then keep-alives as no vehicle moves
+	lines := bufio.NewScanner(resp.Body)
+	if !lines.Scan() || lines.Text() != "retry: 3000" {
+		t.Fatalf("first line = %q, want the retry hint", lines.Text())
+	}
+	var reset bool
+	heartbeats := 0
+	for heartbeats < 3 && lines.Scan() {
+		switch line := lines.Text(); {
+		case line == "event: reset":
+			reset = true
+		case line == ": keep-alive":
+			if !reset {
+				t.Fatal("keep-alive sent before the reset")
+			}
+			heartbeats++
+		case strings.HasPrefix(line, "event: "):
+			t.Errorf("unexpected %q while no vehicle moves", line)
+		}
+	}
+	if heartbeats < 3 {
+		t.Fatalf("got %d keep-alives before the stream ended (%v), want a keep-alive every %s", heartbeats, lines.Err(), sseHeartbeatInterval)
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"explorer/internal/core/usecases"
+	"explorer/internal/infrastructure/config"
+	ports "explorer/internal/ports/streaming"
+	"net/http"
+)
+
+// StreamAlertsHandler is responsible for handling the streaming of service alerts
+// via Server-Sent Events (SSE).
+type StreamAlertsHandler struct {
+	registry ports.StreamRegistry // Provides the shared stream for each upstream query
+}
+
+// NewStreamAlertsHandler creates a new instance of StreamAlertsHandler.
+//
+// Parameters:
+// - registry: The StreamRegistry providing a shared stream per upstream query.
+//
+// Returns:
+// - A pointer to the initialized StreamAlertsHandler.
+func NewStreamAlertsHandler(registry ports.StreamRegistry) *StreamAlertsHandler {
+	return &StreamAlertsHandler{
+		registry: registry,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface and handles SSE streaming of alerts.
+//
+// Parameters:
+// - w: The HTTP response writer to send SSE events to the client.
+// - r: The HTTP request object.
+//
+// Functionality:
+// - Parses the optional route_ids, stop_id, activity and severity query parameters.
+// - Coalesces alert updates to at most max_rate flushes per second, if set.
+// - Resumes from the Last-Event-ID header sent by reconnecting browsers, if any.
+// - Selects the upstream alert stream for the requested filters.
+// - Sends the alerts in effect, then live add, update and remove events until the
+// connection is closed, with keep-alive comments when no data has been sent for a while.
+func (h *StreamAlertsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Parse the filters selecting the alerts to stream (e.g., ?route_ids=Red&severity=7).
+	filter, err := parseAlertQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Limit how often alert updates are sent, if requested (e.g., ?max_rate=1).
+	flushInterval, err := parseMaxRate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Stream alerts to the client as they change, from the shared upstream stream for the requested filters.
+	url := usecases.AlertStreamURL(config.GetAPIBaseURL(), filter)
+	serveSSE(w, r, h.registry, url, usecases.NewStreamUseCase,
+		ports.ClientOptions{
+			FlushInterval: flushInterval, // Coalesce updates sent within this window
+		},
+		formatSSEAlertEvent,
+	)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"explorer/internal/core/usecases"
+	"explorer/internal/infrastructure/config"
+	ports "explorer/internal/ports/streaming"
+	"net/http"
+)
+
+// StreamPredictionsHandler is responsible for handling the streaming of predictions
+// via Server-Sent Events (SSE).
+type StreamPredictionsHandler struct {
+	registry ports.StreamRegistry // Provides the shared stream for each upstream query
+}
+
+// NewStreamPredictionsHandler creates a new instance of StreamPredictionsHandler.
+//
+// Parameters:
+// - registry: The StreamRegistry providing a shared stream per upstream query.
+//
+// Returns:
+// - A pointer to the initialized StreamPredictionsHandler.
+func NewStreamPredictionsHandler(registry ports.StreamRegistry) *StreamPredictionsHandler {
+	return &StreamPredictionsHandler{
+		registry: registry,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface and handles SSE streaming of predictions.
+//
+// Parameters:
+// - w: The HTTP response writer to send SSE events to the client.
+// - r: The HTTP request object.
+//
+// Functionality:
+// - Parses the stop_id and route_ids query parameters, at least one of which is required.
+// - Coalesces prediction updates to at most max_rate flushes per second, if set.
+// - Resumes from the Last-Event-ID header sent by reconnecting browsers, if any.
+// - Selects the upstream prediction stream for the requested stops and routes.
+// - Sends the current predictions, then live add, update and remove events until the
+// connection is closed, with keep-alive comments when no data has been sent for a while.
+func (h *StreamPredictionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Parse the stops and routes to stream predictions for (e.g., ?stop_id=place-pktrm).
+	stopIDs, routeIDs, err := parsePredictionQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Limit how often prediction updates are sent, if requested (e.g., ?max_rate=1).
+	flushInterval, err := parseMaxRate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Stream predictions to the client as they change, from the shared upstream stream for the requested stops and routes.
+	url := usecases.PredictionStreamURL(config.GetAPIBaseURL(), stopIDs, routeIDs)
+	serveSSE(w, r, h.registry, url, usecases.NewStreamUseCase,
+		ports.ClientOptions{
+			FlushInterval: flushInterval, // Coalesce updates sent within this window
+		},
+		formatSSEPredictionEvent,
+	)
+}
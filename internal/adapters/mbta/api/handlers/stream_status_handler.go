@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"encoding/json"
+	"explorer/internal/core/domain/models"
+	ports "explorer/internal/ports/streaming"
+	"log"
+	"net/http"
+)
+
+// streamStatusResponse is the body returned by StreamStatusHandler.
+type streamStatusResponse struct {
+	Streams []models.StreamStatus `json:"streams"`
+}
+
+// StreamStatusHandler is an HTTP handler function that reports the health of every upstream
+// stream: its connection state, when the last event arrived, how many clients are attached,
+// and the counters collected by the stream source and distributor.
+func StreamStatusHandler(registry ports.StreamRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Collect the status of every stream known to the registry
+		response := streamStatusResponse{Streams: registry.Statuses()}
+
+		// Set the Content-Type header to indicate JSON response
+		w.Header().Set("Content-Type", "application/json")
+
+		// Encode the statuses as JSON and send them in the response body
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Error encoding response: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+	}
+}
@@ -1,31 +1,33 @@
 package handlers
 
 import (
-	"explorer/internal/constants"
+	"explorer/internal/core/domain/models"
 	"explorer/internal/core/usecases"
 	"explorer/internal/infrastructure/config"
 	ports "explorer/internal/ports/streaming"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // StreamVehiclesHandler is responsible for handling the streaming of vehicle data
 // via Server-Sent Events (SSE).
 type StreamVehiclesHandler struct {
-	streamManager ports.StreamManager             // Manages the global streaming state
-	useCase       *usecases.StreamVehiclesUseCase // Use case for vehicle streaming logic
+	registry ports.StreamRegistry // Provides the shared stream for each upstream query
 }
 
 // NewStreamVehiclesHandler creates a new instance of StreamVehiclesHandler.
 //
 // Parameters:
-// - sm: The StreamManagerUseCase instance to manage vehicle streams.
+// - registry: The StreamRegistry providing a shared stream per upstream query.
 //
 // Returns:
 // - A pointer to the initialized StreamVehiclesHandler.
-func NewStreamVehiclesHandler(sm ports.StreamManager) *StreamVehiclesHandler {
+func NewStreamVehiclesHandler(registry ports.StreamRegistry) *StreamVehiclesHandler {
 	return &StreamVehiclesHandler{
-		streamManager: sm,
-		useCase:       usecases.NewStreamVehiclesUseCase(sm), // Initialize the streaming use case
+		registry: registry,
 	}
 }
 
@@ -36,30 +38,86 @@ func NewStreamVehiclesHandler(sm ports.StreamManager) *StreamVehiclesHandler {
 // - r: The HTTP request object.
 //
 // Functionality:
-// - Sets up necessary SSE headers.
+// - Parses the optional route_ids, route_type and direction filters from the query string.
+// - Selects JSON or GTFS-Realtime event data from the format parameter or Accept header.
+// - Sends only the changes of updated vehicles, with periodic keyframes, when mode=delta.
+// - Coalesces vehicle updates to at most max_rate flushes per second, if set.
+// - Resumes from the Last-Event-ID header sent by reconnecting browsers, if any.
+// - Selects the upstream stream serving the requested routes.
+// - Sets up necessary SSE headers, disables proxy buffering and sends a retry hint.
 // - Initializes the streaming setup and retrieves a client channel.
-// - Listens for and sends data updates to the client until the connection is closed.
+// - Listens for and sends data updates to the client until the connection is closed,
+// sending keep-alive comments when no data has been sent for a while.
 func (h *StreamVehiclesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Parse the per-client filter so only matching vehicles are sent (e.g., ?route_ids=Red&direction=0).
+	filter, err := parseVehicleFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	// Set SSE-specific headers to enable a persistent connection for streaming data.
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
+	// Select the wire format of the events (e.g., ?format=gtfs-rt).
+	format, err := parseVehicleFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	// Initialize the stream and obtain a dedicated channel for this client.
-	clientChan := h.useCase.StreamSetup(
-		constants.MbtaVehicleLiveStreamUrl, // URL for the MBTA vehicle live stream
-		config.GetAPIKey(),                 // API key for authentication
-	)
-	defer h.streamManager.RemoveClient(clientChan) // Ensure client is removed when function exits.
+	// Send only the changed fields of updated vehicles, if requested (e.g., ?mode=delta).
+	delta, err := parseDeltaMode(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if delta && format != formatJSON {
+		http.Error(w, "the delta mode is only supported with the json format", http.StatusBadRequest)
+		return
+	}
+
+	// Limit how often vehicle updates are sent, if requested (e.g., ?max_rate=1).
+	flushInterval, err := parseMaxRate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	// Handle client disconnection to prevent resource leaks.
-	h.useCase.HandleDisconnect(r.Context(), clientChan)
+	// In delta mode, track the vehicles sent to this client to only send their changes.
+	var deltaEncoder *vehicleDeltaEncoder
+	if delta {
+		deltaEncoder = newVehicleDeltaEncoder(deltaKeyframeInterval)
+	}
+
+	// Stream the vehicles of the shared upstream stream able to serve the requested routes, with
+	// periodic heartbeats so that proxies and load balancers do not close the connection while no vehicle is moving.
+	url := usecases.VehicleStreamFor(filter).URL(config.GetAPIBaseURL())
+	serveSSE(w, r, h.registry, url, usecases.NewStreamVehiclesUseCase,
+		ports.ClientOptions{
+			Filter:        filter,        // Per-client route and direction filter
+			FlushInterval: flushInterval, // Coalesce updates sent within this window
+		},
+		func(event models.StreamEvent) (string, error) {
+			if deltaEncoder != nil {
+				return deltaEncoder.formatSSE(event)
+			}
+			return formatSSEVehicleEvent(event, format)
+		},
+	)
+}
 
-	// Stream data to the client as it becomes available.
-	flusher := w.(http.Flusher) // Ensure the response writer supports flushing.
-	for data := range clientChan {
-		_, _ = w.Write([]byte(data)) // Send data to the client
-		flusher.Flush()              // Ensure data is immediately sent
+// parseMaxRate reads the max_rate query parameter, the maximum number of times per second
+// the client wants to receive vehicle updates (e.g., ?max_rate=1 or ?max_rate=0.5).
+//
+// Returns:
+// - The interval between flushes of the client's events, 0 if the parameter is not set.
+// - An error if the parameter is not a positive number.
+func parseMaxRate(r *http.Request) (time.Duration, error) {
+	value := r.URL.Query().Get("max_rate")
+	if value == "" {
+		return 0, nil
+	}
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate <= 0 || math.IsInf(rate, 0) {
+		return 0, fmt.Errorf("invalid max_rate %q, expected a positive number of updates per second", value)
 	}
+	return time.Duration(float64(time.Second) / rate), nil
 }
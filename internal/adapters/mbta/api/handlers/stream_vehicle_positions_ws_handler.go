@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"explorer/internal/core/domain/models"
+	"explorer/internal/core/usecases"
+	"explorer/internal/infrastructure/config"
+	"explorer/internal/infrastructure/middleware"
+	ports "explorer/internal/ports/streaming"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteWait      = 10 * time.Second    // Time allowed to write a message to the client
+	wsPongWait       = 60 * time.Second    // Time allowed to read the next pong from the client
+	wsPingPeriod     = wsPongWait * 9 / 10 // Send pings to the client with this period, must be less than wsPongWait
+	wsMaxMessageSize = 4096                // Maximum size of a message received from the client
+)
+
+// wsMessage is the JSON envelope of every WebSocket message sent to the client.
+// Event carries the same event names as the SSE stream ("reset", "add", "update", "remove").
+type wsMessage struct {
+	ID    string          `json:"id,omitempty"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// wsCommand is a message received from the client to change its subscription at runtime.
+//
+// Examples:
+// - {"action": "subscribe", "route_ids": ["Red", "Orange"], "direction": 0}
+// - {"action": "unsubscribe", "route_ids": ["Orange"]}
+type wsCommand struct {
+	Action    string   `json:"action"`
+	RouteIDs  []string `json:"route_ids"`
+	Direction *int     `json:"direction,omitempty"`
+}
+
+// wsSubscription is the payload of the "subscribed" message acknowledging a command.
+type wsSubscription struct {
+	RouteIDs   []string `json:"route_ids"`
+	RouteTypes []int    `json:"route_types,omitempty"`
+	Direction  *int     `json:"direction"`
+}
+
+// StreamVehiclesWSHandler is responsible for handling the streaming of vehicle data
+// via WebSocket. It is fed by the same distributor as StreamVehiclesHandler.
+type StreamVehiclesWSHandler struct {
+	registry ports.StreamRegistry // Provides the shared stream for each upstream query
+	upgrader websocket.Upgrader   // Upgrades HTTP connections to WebSocket
+}
+
+// wsClient holds the state of a single WebSocket connection.
+type wsClient struct {
+	conn    *websocket.Conn
+	stream  usecases.VehicleStream // Routes or route types carried by the upstream stream
+	filter  models.VehicleFilter   // Current subscription, owned by the read loop
+	replies chan wsReply           // Replies to commands, written by the write loop
+}
+
+// wsReply is the reply to a client command, along with the subscription it applies, if any.
+// The write loop sends the reply and only then applies the subscription, so the client receives
+// the "subscribed" message before the reset with its new vehicles.
+type wsReply struct {
+	message wsMessage
+	filter  *models.VehicleFilter // The new subscription, nil if the command was rejected
+}
+
+// NewStreamVehiclesWSHandler creates a new instance of StreamVehiclesWSHandler.
+//
+// Parameters:
+// - registry: The StreamRegistry providing a shared stream per upstream query.
+//
+// Returns:
+// - A pointer to the initialized StreamVehiclesWSHandler.
+func NewStreamVehiclesWSHandler(registry ports.StreamRegistry) *StreamVehiclesWSHandler {
+	return &StreamVehiclesWSHandler{
+		registry: registry,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     middleware.IsAllowedOrigin, // Apply the same origins as the CORS configuration
+		},
+	}
+}
+
+// ServeHTTP implements the http.Handler interface and handles WebSocket streaming.
+//
+// Parameters:
+// - w: The HTTP response writer used to upgrade the connection.
+// - r: The HTTP request object.
+//
+// Functionality:
+// - Parses the optional route_ids, route_type and direction filters and the max_rate limit from the query string.
+// - Selects JSON messages or binary GTFS-Realtime feeds from the format parameter or Accept header.
+// - Upgrades the connection and registers a client channel with the stream serving the routes.
+// - Reads subscribe/unsubscribe commands from the client in a separate goroutine.
+// - Sends data updates and keep-alive pings until either side closes the connection.
+func (h *StreamVehiclesWSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Parse the initial per-client filter (e.g., ?route_ids=Red&direction=0).
+	filter, err := parseVehicleFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Select JSON messages or binary GTFS-Realtime feeds (e.g., ?format=gtfs-rt).
+	format, err := parseVehicleFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format == formatGTFSRTText {
+		http.Error(w, "the gtfs-rt-text format is not supported over WebSocket", http.StatusBadRequest)
+		return
+	}
+
+	// Limit how often vehicle updates are sent, if requested (e.g., ?max_rate=1).
+	flushInterval, err := parseMaxRate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Upgrade the HTTP connection. On failure the upgrader has already replied to the client.
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Error upgrading to WebSocket: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// The connection is hijacked, so cancel our own context once the client goes away.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// Select the shared upstream stream able to serve the requested routes.
+	stream := usecases.VehicleStreamFor(filter)
+	url := stream.URL(config.GetAPIBaseURL())
+	streamManager, release := h.registry.Acquire(url)
+	defer release() // Let the registry discard the stream once it is idle
+	useCase := usecases.NewStreamVehiclesUseCase(streamManager)
+
+	// Initialize the stream and obtain a dedicated channel for this client.
+	clientChan := useCase.StreamSetup(
+		url,                // URL for the MBTA vehicle live stream
+		config.GetAPIKey(), // API key for authentication
+		ports.ClientOptions{
+			Filter:        filter,        // Initial route and direction filter
+			FlushInterval: flushInterval, // Coalesce updates sent within this window
+		},
+	)
+	defer streamManager.RemoveClient(clientChan) // Ensure client is removed when function exits.
+
+	// Handle client disconnection to prevent resource leaks.
+	useCase.HandleDisconnect(ctx, clientChan)
+
+	// Replies to client commands are written by this goroutine only, as required by the connection.
+	client := &wsClient{
+		conn:    conn,
+		stream:  stream,
+		filter:  filter,
+		replies: make(chan wsReply, 10),
+	}
+	go client.readCommands(ctx, cancel)
+	replies := client.replies
+
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-clientChan:
+			if !ok {
+				// The client was removed from the distributor; close the connection cleanly.
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(wsWriteWait))
+				return
+			}
+			if err := writeWSVehicleEvent(conn, event, format); err != nil {
+				return
+			}
+		case reply := <-replies:
+			if err := writeWSMessage(conn, reply.message); err != nil {
+				return
+			}
+			if reply.filter != nil {
+				// Resend the matching vehicles now that the client knows its new subscription.
+				useCase.UpdateFilter(clientChan, *reply.filter)
+			}
+		case <-ticker.C:
+			// Ping the client so dead connections are detected by the read deadline.
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// readCommands reads subscription commands from the client and applies them to its filter.
+// It cancels the connection context when the client disconnects or stops answering pings.
+func (c *wsClient) readCommands(ctx context.Context, cancel context.CancelFunc) {
+	defer cancel()
+	conn := c.conn
+
+	conn.SetReadLimit(wsMaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, payload, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("WebSocket read error: %v", err)
+			}
+			return
+		}
+
+		// Decode the command, replying with either the new subscription, applied by the write loop, or an error.
+		var reply wsReply
+		var cmd wsCommand
+		if err := json.Unmarshal(payload, &cmd); err != nil {
+			reply.message = newWSError(fmt.Errorf("invalid command: %w", err))
+		} else if next, err := applyWSCommand(c.filter, cmd); err != nil {
+			reply.message = newWSError(err)
+		} else if (next.RouteIDs != nil || next.RouteTypes != nil) && !c.stream.Serves(next) {
+			// Changing upstream streams would require a new connection.
+			reply.message = newWSError(fmt.Errorf("routes must be among the %s carried by this connection, reconnect with route_ids or route_type to change", c.stream))
+		} else {
+			c.filter = next
+			data, _ := json.Marshal(wsSubscription{RouteIDs: c.filter.RouteIDs, RouteTypes: c.filter.RouteTypes, Direction: c.filter.Direction})
+			reply = wsReply{message: wsMessage{Event: "subscribed", Data: data}, filter: &next}
+		}
+
+		select {
+		case c.replies <- reply:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// applyWSCommand returns the filter that results from applying a client command.
+//
+// Parameters:
+// - filter: The client's current filter.
+// - cmd: The command received from the client.
+//
+// Returns:
+// - The updated filter, or an error if the command is not valid for the current filter.
+func applyWSCommand(filter models.VehicleFilter, cmd wsCommand) (models.VehicleFilter, error) {
+	if cmd.Direction != nil && *cmd.Direction != 0 && *cmd.Direction != 1 {
+		return filter, fmt.Errorf("invalid direction %d, expected 0 or 1", *cmd.Direction)
+	}
+
+	switch cmd.Action {
+	case "subscribe":
+		// Subscribing narrows an all-routes subscription, otherwise it adds to the current routes.
+		if len(cmd.RouteIDs) > 0 {
+			routeIDs := []string{}
+			if filter.RouteIDs != nil {
+				routeIDs = append(routeIDs, filter.RouteIDs...)
+			}
+			for _, routeID := range cmd.RouteIDs {
+				if !slices.Contains(routeIDs, routeID) {
+					routeIDs = append(routeIDs, routeID)
+				}
+			}
+			filter.RouteIDs = routeIDs
+		}
+		if cmd.Direction != nil {
+			filter.Direction = cmd.Direction
+		}
+
+	case "unsubscribe":
+		// Unsubscribing without route IDs stops all vehicle events until the next subscribe.
+		if len(cmd.RouteIDs) == 0 {
+			filter.RouteIDs = []string{}
+			return filter, nil
+		}
+		if filter.RouteIDs == nil {
+			return filter, fmt.Errorf("cannot unsubscribe from individual routes while subscribed to all routes")
+		}
+		routeIDs := []string{}
+		for _, routeID := range filter.RouteIDs {
+			if !slices.Contains(cmd.RouteIDs, routeID) {
+				routeIDs = append(routeIDs, routeID)
+			}
+		}
+		filter.RouteIDs = routeIDs
+
+	default:
+		return filter, fmt.Errorf("unknown action %q, expected subscribe or unsubscribe", cmd.Action)
+	}
+
+	return filter, nil
+}
+
+// writeWSMessage writes a JSON message to the client with a write deadline.
+func writeWSMessage(conn *websocket.Conn, msg wsMessage) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return conn.WriteJSON(msg)
+}
+
+// writeWSVehicleEvent serializes a vehicle event for the WebSocket transport and writes it.
+// JSON events are sent as text messages with the wsMessage envelope, GTFS-Realtime feeds as
+// binary messages. Events that cannot be encoded are logged and skipped.
+//
+// Returns:
+// - An error if the connection failed.
+func writeWSVehicleEvent(conn *websocket.Conn, event models.StreamEvent, format vehicleFormat) error {
+	if format == formatGTFSRT {
+		data, err := encodeVehicleEventAs(event, format)
+		if err != nil {
+			log.Printf("Failed to encode %s event: %v", event.EventName(), err)
+			return nil
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		return conn.WriteMessage(websocket.BinaryMessage, data)
+	}
+
+	msg, err := newWSVehicleMessage(event)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", event.EventName(), err)
+		return nil
+	}
+	return writeWSMessage(conn, msg)
+}
+
+// newWSError builds an "error" message to report a rejected command to the client.
+func newWSError(err error) wsMessage {
+	data, _ := json.Marshal(map[string]string{"message": err.Error()})
+	return wsMessage{Event: "error", Data: data}
+}
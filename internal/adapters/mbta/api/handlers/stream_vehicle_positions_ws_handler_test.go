@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	ports "explorer/internal/ports/streaming"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// readWSEvent reads the next JSON message from the connection and returns its event name
+func readWSEvent(t *testing.T, conn *websocket.Conn) string {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg wsMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("failed to read message: %v", err)
+	}
+	return msg.Event
+}
+
+func TestWSSubscribeRepliesBeforeReset(t *testing.T) {
+	server := newStreamTestServer(t, func(registry ports.StreamRegistry) http.Handler {
+		return NewStreamVehiclesWSHandler(registry)
+	})
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"?route_ids=Red", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if event := readWSEvent(t, conn); event != "reset" {
+		t.Fatalf("first message is %q, want the reset", event)
+	}
+
+	// Every accepted command is acknowledged before the reset with the vehicles it selects
+	for i := range 20 {
+		direction := i % 2
+		if err := conn.WriteJSON(wsCommand{Action: "subscribe", Direction: &direction}); err != nil {
+			t.Fatal(err)
+		}
+		if event := readWSEvent(t, conn); event != "subscribed" {
+			t.Fatalf("command %d: got %q, want subscribed first", i, event)
+		}
+		if event := readWSEvent(t, conn); event != "reset" {
+			t.Fatalf("command %d: got %q, want the reset after subscribed", i, event)
+		}
+	}
+}
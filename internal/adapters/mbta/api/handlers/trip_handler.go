@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"explorer/internal/core/usecases"
+	"explorer/internal/ports/data"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// TripHandler is an HTTP handler function that returns the trip named in the request path with its
+// headsign, ordered stops with scheduled and predicted times, assigned vehicle and shape.
+func TripHandler(useCases usecases.MbtaApiHelper) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Extract the trip ID from the path (e.g., /api/trips/67268866)
+		tripID := mux.Vars(r)["id"]
+
+		// Assemble the trip from its schedules, predictions, vehicle and shape
+		trip, err := useCases.GetTrip(r.Context(), tripID)
+		if errors.Is(err, data.ErrNotFound) {
+			http.Error(w, "Trip not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Println("Error fetching trip:", err)
+			http.Error(w, "Error fetching trip", http.StatusInternalServerError)
+			return
+		}
+
+		// Set the response header to specify that the content being returned is in JSON format
+		w.Header().Set("Content-Type", "application/json")
+
+		// Encode the trip as JSON and send it in the response body
+		json.NewEncoder(w).Encode(trip)
+	}
+}
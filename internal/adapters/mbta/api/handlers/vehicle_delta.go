@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"explorer/internal/core/domain/models"
+	"explorer/internal/pkg"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	// deltaKeyframeInterval is how often a delta stream resends the full vehicle set,
+	// so clients never drift from the server state for long.
+	deltaKeyframeInterval = time.Minute
+
+	// deltaPatchEvent is the name of the events carrying only the changed fields of a vehicle, so
+	// clients never mistake them for the full vehicle sent by an update.
+	deltaPatchEvent = "patch"
+)
+
+// parseDeltaMode reads the mode query parameter of the vehicle stream.
+//
+// Returns:
+// - true for ?mode=delta, false for ?mode=full or no mode, or an error for any other value.
+func parseDeltaMode(r *http.Request) (bool, error) {
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", "full":
+		return false, nil
+	case "delta":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid mode %q, expected full or delta", mode)
+	}
+}
+
+// sentVehicle is the last state of a vehicle sent to a delta client, split into fields
+// so that changes can be detected without decoding the vehicle again.
+type sentVehicle struct {
+	vehicle    models.Vehicle
+	fields     map[string]json.RawMessage // Top-level fields, except attributes
+	attributes map[string]json.RawMessage // Fields of the attributes object
+}
+
+// vehicleDeltaEncoder serializes the events of a single client in delta mode. Updates to a
+// vehicle the client already has are sent as patch events, only carrying the vehicle ID and the
+// fields that changed since the last event sent for it. Once deltaKeyframeInterval has passed
+// since the last reset, the next add or update is replaced by a full reset event (a keyframe).
+// Keyframes are not sent on a timer: a stream without changes has nothing the client could drift from.
+type vehicleDeltaEncoder struct {
+	sent         map[string]sentVehicle // Vehicles the client has, by ID
+	lastKeyframe time.Time              // When the full vehicle set was last sent
+	interval     time.Duration          // Time between keyframes
+}
+
+// newVehicleDeltaEncoder initializes an encoder sending a keyframe every interval.
+func newVehicleDeltaEncoder(interval time.Duration) *vehicleDeltaEncoder {
+	return &vehicleDeltaEncoder{
+		sent:         make(map[string]sentVehicle),
+		lastKeyframe: time.Now(),
+		interval:     interval,
+	}
+}
+
+// formatSSE serializes a vehicle event as an SSE message in delta mode.
+//
+// Returns:
+// - The SSE message, or an empty string if the event changes nothing the client has.
+// - An error if the event cannot be encoded.
+func (d *vehicleDeltaEncoder) formatSSE(event models.StreamEvent) (string, error) {
+	name, data, err := d.encode(event)
+	if err != nil || data == nil {
+		return "", err
+	}
+	return pkg.FormatSSE(pkg.SSEEvent{ID: formatEventID(event), Event: name, Data: string(data)}), nil
+}
+
+// encode returns the event name and JSON payload to send for an event, updating the
+// state sent to the client. A nil payload means there is nothing to send.
+func (d *vehicleDeltaEncoder) encode(event models.StreamEvent) (string, []byte, error) {
+	switch e := event.(type) {
+	case models.VehicleReset:
+		d.sent = make(map[string]sentVehicle, len(e.Vehicles))
+		for _, vehicle := range e.Vehicles {
+			if err := d.remember(vehicle); err != nil {
+				return "", nil, err
+			}
+		}
+		d.lastKeyframe = time.Now()
+		data, err := encodeVehicleEvent(e)
+		return e.EventName(), data, err
+
+	case models.VehicleAdded:
+		return d.encodeVehicle(e, e.Vehicle)
+
+	case models.VehicleUpdated:
+		return d.encodeVehicle(e, e.Vehicle)
+
+	case models.VehicleRemoved:
+		delete(d.sent, e.VehicleID)
+		data, err := encodeVehicleEvent(e)
+		return e.EventName(), data, err
+	}
+	return "", nil, fmt.Errorf("unsupported vehicle event %T", event)
+}
+
+// encodeVehicle returns the event name and payload for an add or update of vehicle: a keyframe
+// if one is due, the full vehicle if the client does not have it yet, or a patch with only its changes.
+func (d *vehicleDeltaEncoder) encodeVehicle(event models.StreamEvent, vehicle models.Vehicle) (string, []byte, error) {
+	previous, known := d.sent[vehicle.ID]
+	if err := d.remember(vehicle); err != nil {
+		return "", nil, err
+	}
+	current := d.sent[vehicle.ID]
+
+	if time.Since(d.lastKeyframe) >= d.interval {
+		return d.encodeKeyframe()
+	}
+	if !known {
+		data, err := encodeVehicleEvent(event)
+		return event.EventName(), data, err
+	}
+
+	changes := diffFields(previous.fields, current.fields)
+	if attributes := diffFields(previous.attributes, current.attributes); len(attributes) > 0 {
+		changes["attributes"] = attributes
+	}
+	if len(changes) == 0 {
+		return "", nil, nil // Nothing the client does not already have
+	}
+	changes["id"] = vehicle.ID
+
+	data, err := json.Marshal(changes)
+	return deltaPatchEvent, data, err
+}
+
+// encodeKeyframe returns a reset event carrying every vehicle the client has, sorted by ID.
+func (d *vehicleDeltaEncoder) encodeKeyframe() (string, []byte, error) {
+	vehicles := make([]models.Vehicle, 0, len(d.sent))
+	for _, sent := range d.sent {
+		vehicles = append(vehicles, sent.vehicle)
+	}
+	sort.Slice(vehicles, func(i, j int) bool { return vehicles[i].ID < vehicles[j].ID })
+
+	d.lastKeyframe = time.Now()
+	data, err := encodeVehicleEvent(models.VehicleReset{Vehicles: vehicles})
+	return models.ResetEvent, data, err
+}
+
+// remember records vehicle as the last state sent to the client.
+func (d *vehicleDeltaEncoder) remember(vehicle models.Vehicle) error {
+	encoded, err := json.Marshal(vehicle)
+	if err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return err
+	}
+	var attributes map[string]json.RawMessage
+	if err := json.Unmarshal(fields["attributes"], &attributes); err != nil {
+		return err
+	}
+	delete(fields, "attributes")
+
+	d.sent[vehicle.ID] = sentVehicle{vehicle: vehicle, fields: fields, attributes: attributes}
+	return nil
+}
+
+// diffFields returns the fields of current whose value differs from previous. Fields missing
+// from current are reported as null.
+func diffFields(previous, current map[string]json.RawMessage) map[string]any {
+	changes := make(map[string]any)
+	for name, value := range current {
+		if !bytes.Equal(previous[name], value) {
+			changes[name] = value
+		}
+	}
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			changes[name] = nil
+		}
+	}
+	return changes
+}
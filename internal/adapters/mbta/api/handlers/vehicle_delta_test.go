@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"explorer/internal/core/domain/models"
+	"testing"
+	"time"
+)
+
+// deltaVehicle returns a Red Line vehicle at the given position
+func deltaVehicle(id string, latitude float64, status string) models.Vehicle {
+	return models.Vehicle{
+		ID:    id,
+		Route: "Red",
+		Attributes: models.VehicleAttributes{
+			Latitude:      latitude,
+			Longitude:     -71.06,
+			CurrentStatus: status,
+			UpdatedAt:     "2025-01-11T21:13:39-05:00",
+		},
+	}
+}
+
+// encodeDelta encodes an event and decodes its payload, failing the test on errors
+func encodeDelta(t *testing.T, encoder *vehicleDeltaEncoder, event models.StreamEvent) (string, map[string]any) {
+	t.Helper()
+	name, data, err := encoder.encode(event)
+	if err != nil {
+		t.Fatalf("encode %T: %v", event, err)
+	}
+	if data == nil {
+		return name, nil
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(data, &payload); err != nil {
+		var vehicles []any // Resets carry a list of vehicles
+		if err := json.Unmarshal(data, &vehicles); err != nil {
+			t.Fatalf("payload of %s is not JSON: %s", name, data)
+		}
+		return name, map[string]any{"vehicles": vehicles}
+	}
+	return name, payload
+}
+
+func TestVehicleDeltaEncoderSendsChangedFields(t *testing.T) {
+	encoder := newVehicleDeltaEncoder(time.Hour)
+	encodeDelta(t, encoder, models.VehicleReset{Vehicles: []models.Vehicle{deltaVehicle("R-1", 42.35, "STOPPED_AT")}})
+
+	tests := []struct {
+		name    string
+		event   models.StreamEvent
+		want    string         // Event name, empty when nothing is sent
+		changes map[string]any // Expected payload of a patch
+	}{
+		{
+			name:  "changed attributes",
+			event: models.VehicleUpdated{Vehicle: deltaVehicle("R-1", 42.36, "IN_TRANSIT_TO")},
+			want:  deltaPatchEvent,
+			changes: map[string]any{
+				"id":         "R-1",
+				"attributes": map[string]any{"latitude": 42.36, "current_status": "IN_TRANSIT_TO"},
+			},
+		},
+		{
+			name:  "unchanged vehicle",
+			event: models.VehicleUpdated{Vehicle: deltaVehicle("R-1", 42.36, "IN_TRANSIT_TO")},
+			want:  "",
+		},
+		{
+			name: "changed top-level field",
+			event: models.VehicleUpdated{Vehicle: func() models.Vehicle {
+				vehicle := deltaVehicle("R-1", 42.36, "IN_TRANSIT_TO")
+				vehicle.Mode = "subway"
+				return vehicle
+			}()},
+			want:    deltaPatchEvent,
+			changes: map[string]any{"id": "R-1", "mode": "subway"},
+		},
+		{
+			name:  "vehicle the client does not have",
+			event: models.VehicleUpdated{Vehicle: deltaVehicle("R-2", 42.30, "STOPPED_AT")},
+			want:  models.UpdatedEvent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, payload := encodeDelta(t, encoder, tt.event)
+			if name != tt.want {
+				t.Fatalf("event name = %q, want %q", name, tt.want)
+			}
+			if tt.changes != nil {
+				got, _ := json.Marshal(payload)
+				want, _ := json.Marshal(tt.changes)
+				if string(got) != string(want) {
+					t.Errorf("patch = %s, want %s", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestVehicleDeltaEncoderSendsKeyframe(t *testing.T) {
+	encoder := newVehicleDeltaEncoder(time.Hour)
+	encodeDelta(t, encoder, models.VehicleReset{Vehicles: []models.Vehicle{
+		deltaVehicle("R-2", 42.30, "STOPPED_AT"),
+		deltaVehicle("R-1", 42.35, "STOPPED_AT"),
+	}})
+
+	// Once the interval has passed, the next update is replaced by the full vehicle set
+	encoder.lastKeyframe = time.Now().Add(-time.Hour)
+	name, payload := encodeDelta(t, encoder, models.VehicleUpdated{Vehicle: deltaVehicle("R-1", 42.36, "IN_TRANSIT_TO")})
+	if name != models.ResetEvent {
+		t.Fatalf("event name = %q, want a reset keyframe", name)
+	}
+	vehicles, _ := payload["vehicles"].([]any)
+	if len(vehicles) != 2 {
+		t.Fatalf("keyframe carries %d vehicles, want 2", len(vehicles))
+	}
+	first := vehicles[0].(map[string]any)
+	if first["id"] != "R-1" || first["attributes"].(map[string]any)["latitude"] != 42.36 {
+		t.Errorf("first keyframe vehicle = %v, want R-1 with its update applied", first)
+	}
+
+	// The next update is a patch again
+	if name, _ := encodeDelta(t, encoder, models.VehicleUpdated{Vehicle: deltaVehicle("R-1", 42.37, "IN_TRANSIT_TO")}); name != deltaPatchEvent {
+		t.Errorf("event name after the keyframe = %q, want %q", name, deltaPatchEvent)
+	}
+}
+
+func TestVehicleDeltaEncoderForgetsRemovedVehicles(t *testing.T) {
+	encoder := newVehicleDeltaEncoder(time.Hour)
+	encodeDelta(t, encoder, models.VehicleReset{Vehicles: []models.Vehicle{deltaVehicle("R-1", 42.35, "STOPPED_AT")}})
+
+	name, payload := encodeDelta(t, encoder, models.VehicleRemoved{VehicleID: "R-1"})
+	if name != models.RemovedEvent || payload["id"] != "R-1" {
+		t.Fatalf("got %s %v, want the remove of R-1", name, payload)
+	}
+
+	// A vehicle coming back after its remove is sent whole, not as a patch
+	name, payload = encodeDelta(t, encoder, models.VehicleAdded{Vehicle: deltaVehicle("R-1", 42.35, "STOPPED_AT")})
+	if name != models.AddedEvent || payload["route"] != "Red" {
+		t.Errorf("got %s %v, want the full add of R-1", name, payload)
+	}
+
+	// A keyframe after a remove leaves the vehicle out
+	encodeDelta(t, encoder, models.VehicleRemoved{VehicleID: "R-1"})
+	encoder.lastKeyframe = time.Now().Add(-time.Hour)
+	_, payload = encodeDelta(t, encoder, models.VehicleAdded{Vehicle: deltaVehicle("R-2", 42.30, "STOPPED_AT")})
+	if vehicles, _ := payload["vehicles"].([]any); len(vehicles) != 1 || vehicles[0].(map[string]any)["id"] != "R-2" {
+		t.Errorf("keyframe = %v, want R-2 only", payload)
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"explorer/internal/adapters/gtfsrt"
+	"explorer/internal/core/domain/models"
+	"explorer/internal/pkg"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// encodeVehicleEvent serializes the payload of a vehicle event as JSON, in the same shape as
+// the MBTA streaming API: a list of vehicles for a reset, a single vehicle for an add or update,
+// and a resource identifier for a remove.
+func encodeVehicleEvent(event models.StreamEvent) ([]byte, error) {
+	switch e := event.(type) {
+	case models.VehicleReset:
+		if e.Vehicles == nil {
+			e.Vehicles = []models.Vehicle{} // Encode an empty reset as [] rather than null
+		}
+		return json.Marshal(e.Vehicles)
+	case models.VehicleAdded:
+		return json.Marshal(e.Vehicle)
+	case models.VehicleUpdated:
+		return json.Marshal(e.Vehicle)
+	case models.VehicleRemoved:
+		return json.Marshal(models.RouteData{ID: e.VehicleID, Type: "vehicle"})
+	}
+	return nil, fmt.Errorf("unsupported vehicle event %T", event)
+}
+
+// encodeVehicleEventAs serializes the payload of a vehicle event in the given format.
+// GTFS-Realtime formats carry the event as a FeedMessage, see gtfsrt.NewVehicleEventFeed.
+func encodeVehicleEventAs(event models.StreamEvent, format vehicleFormat) ([]byte, error) {
+	if format == formatJSON {
+		return encodeVehicleEvent(event)
+	}
+
+	feed := gtfsrt.NewVehicleEventFeed(event, time.Now())
+	if feed == nil {
+		return nil, fmt.Errorf("unsupported vehicle event %T", event)
+	}
+	if format == formatGTFSRTText {
+		return gtfsrt.MarshalText(feed)
+	}
+	return gtfsrt.Marshal(feed)
+}
+
+// formatEventID formats an event ID for the wire, leaving it empty for unnumbered events.
+func formatEventID(event models.StreamEvent) string {
+	if event.EventID() == 0 {
+		return ""
+	}
+	return strconv.FormatUint(event.EventID(), 10)
+}
+
+// formatSSEVehicleEvent serializes a vehicle event as an SSE message in the given format.
+// SSE only carries text, so the GTFS-Realtime binary format is base64 encoded.
+func formatSSEVehicleEvent(event models.StreamEvent, format vehicleFormat) (string, error) {
+	data, err := encodeVehicleEventAs(event, format)
+	if err != nil {
+		return "", err
+	}
+	payload := string(data)
+	if format == formatGTFSRT {
+		payload = base64.StdEncoding.EncodeToString(data)
+	}
+	return pkg.FormatSSE(pkg.SSEEvent{ID: formatEventID(event), Event: event.EventName(), Data: payload}), nil
+}
+
+// newWSVehicleMessage serializes a vehicle event as a JSON WebSocket message.
+func newWSVehicleMessage(event models.StreamEvent) (wsMessage, error) {
+	data, err := encodeVehicleEvent(event)
+	if err != nil {
+		return wsMessage{}, err
+	}
+	return wsMessage{ID: formatEventID(event), Event: event.EventName(), Data: data}, nil
+}
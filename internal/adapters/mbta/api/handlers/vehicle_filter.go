@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"explorer/internal/core/domain/models"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// parseVehicleFilter builds a VehicleFilter from the request query parameters.
+//
+// Supported parameters:
+// - route_ids: Comma-separated list of route IDs (e.g., ?route_ids=Red,Orange).
+// - route_type: Comma-separated list of route types (e.g., ?route_type=0,1 for the subway).
+// - direction: Direction ID, either 0 or 1 (e.g., ?direction=0).
+//
+// Returns:
+// - The parsed filter, or an error if a parameter has an invalid value.
+func parseVehicleFilter(r *http.Request) (models.VehicleFilter, error) {
+	var filter models.VehicleFilter
+	query := r.URL.Query()
+
+	filter.RouteIDs = parseIDs(r, "route_ids")
+
+	routeTypes, err := parseRouteTypes(r)
+	if err != nil {
+		return models.VehicleFilter{}, err
+	}
+	filter.RouteTypes = routeTypes
+
+	if strDirection := query.Get("direction"); strDirection != "" {
+		direction, err := strconv.Atoi(strDirection)
+		if err != nil || (direction != 0 && direction != 1) {
+			return models.VehicleFilter{}, fmt.Errorf("invalid direction %q, expected 0 or 1", strDirection)
+		}
+		filter.Direction = &direction
+	}
+
+	return filter, nil
+}
+
+// parseRouteTypes reads the comma-separated route_type query parameter (e.g., ?route_type=3 for buses).
+//
+// Returns:
+// - The route types, nil if the parameter is not set, or an error if a value is not a known route type.
+func parseRouteTypes(r *http.Request) ([]int, error) {
+	var routeTypes []int
+	for _, strRouteType := range strings.Split(r.URL.Query().Get("route_type"), ",") {
+		if strRouteType = strings.TrimSpace(strRouteType); strRouteType == "" {
+			continue
+		}
+		routeType, err := strconv.Atoi(strRouteType)
+		if err != nil || !models.IsValidRouteType(routeType) {
+			return nil, fmt.Errorf("invalid route_type %q, expected 0 (light rail), 1 (subway), 2 (commuter rail), 3 (bus) or 4 (ferry)", strRouteType)
+		}
+		routeTypes = append(routeTypes, routeType)
+	}
+	return routeTypes, nil
+}
+
+// parseIDs reads a comma-separated list of IDs from the named query parameter
+// (e.g., ?route_ids=Red,Orange), ignoring empty entries.
+//
+// Returns:
+// - The IDs, nil if the parameter is not set or empty.
+func parseIDs(r *http.Request, name string) []string {
+	var ids []string
+	for _, id := range strings.Split(r.URL.Query().Get(name), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// vehicleFormat is the wire format used to send vehicles to a client.
+type vehicleFormat string
+
+const (
+	formatJSON       vehicleFormat = "json"         // JSON, the default
+	formatGTFSRT     vehicleFormat = "gtfs-rt"      // GTFS-Realtime FeedMessage in the Protocol Buffers binary format
+	formatGTFSRTText vehicleFormat = "gtfs-rt-text" // GTFS-Realtime FeedMessage in the Protocol Buffers text format, for debugging
+)
+
+// Content types of the GTFS-Realtime formats
+const (
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeText     = "text/plain; charset=utf-8"
+)
+
+// protobufMediaTypes are the Accept media types selecting the GTFS-Realtime binary format.
+var protobufMediaTypes = []string{"application/x-protobuf", "application/protobuf", "application/vnd.google.protobuf"}
+
+// parseVehicleFormat selects the wire format from the format query parameter
+// (e.g., ?format=gtfs-rt) or, if it is not set, from the Accept header.
+//
+// Returns:
+// - The selected format, JSON by default, or an error if the format parameter is not supported.
+func parseVehicleFormat(r *http.Request) (vehicleFormat, error) {
+	if value := r.URL.Query().Get("format"); value != "" {
+		switch format := vehicleFormat(value); format {
+		case formatJSON, formatGTFSRT, formatGTFSRTText:
+			return format, nil
+		default:
+			return "", fmt.Errorf("invalid format %q, expected %s, %s or %s", value, formatJSON, formatGTFSRT, formatGTFSRTText)
+		}
+	}
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		if slices.Contains(protobufMediaTypes, mediaType) {
+			return formatGTFSRT, nil
+		}
+	}
+	return formatJSON, nil
+}
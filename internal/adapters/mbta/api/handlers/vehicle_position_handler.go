@@ -2,25 +2,56 @@ package handlers
 
 import (
 	"encoding/json"
+	"explorer/internal/adapters/gtfsrt"
+	"explorer/internal/core/domain/models"
 	"explorer/internal/core/usecases"
+	"explorer/internal/infrastructure/config"
+	ports "explorer/internal/ports/streaming"
 	"log"
 	"net/http"
+	"time"
 )
 
 // UpdateLiveData is an HTTP handler function that returns the live data of vehicles for a given route.
-// It extracts the route ID from the request query parameters and calls the FetchData service to retrieve live data (vehicles).
-func VehiclePositionHandler(fetchData usecases.MbtaApiHelper) http.HandlerFunc {
+// It extracts the route ID from the request query parameters and reads the vehicles from the running
+// upstream stream serving them, if any, or calls the FetchData service to retrieve live data (vehicles).
+func VehiclePositionHandler(fetchData usecases.MbtaApiHelper, registry ports.StreamRegistry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// Select JSON or GTFS-Realtime output (e.g., ?format=gtfs-rt or Accept: application/x-protobuf)
+		format, err := parseVehicleFormat(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		// Extract the route ID from the query parameters of the URL (e.g., /live-data?route_id=Red)
 		routeID := r.URL.Query().Get("route_ids")
 
-		// Call the GetLiveData method of the fetchData service to get the live data for the given route ID
-		vehicles, err := fetchData.GetLiveData(routeID)
-
-		// If an error occurred while fetching the live data, log the error and return a 500 Internal Server Error
+		// Extract the optional route types (e.g., ?route_type=3 for buses)
+		routeTypes, err := parseRouteTypes(r)
 		if err != nil {
-			log.Println("Error in UpdateLiveData:", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Read the current vehicles from a running stream carrying them, which is kept up to date anyway
+		filter := models.VehicleFilter{RouteIDs: parseIDs(r, "route_ids"), RouteTypes: routeTypes}
+		vehicles, ok := usecases.CurrentVehicles(registry, config.GetAPIBaseURL(), filter)
+		if !ok {
+			// Call the GetLiveData method of the fetchData service to get the live data for the given route ID and types
+			vehicles, err = fetchData.GetLiveData(r.Context(), routeID, routeTypes)
+
+			// If an error occurred while fetching the live data, log the error and return a 500 Internal Server Error
+			if err != nil {
+				log.Println("Error in UpdateLiveData:", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// Send the vehicles as a GTFS-Realtime VehiclePositions feed if requested
+		if format != formatJSON {
+			writeVehiclePositionsFeed(w, vehicles, format)
 			return
 		}
 
@@ -31,3 +62,24 @@ func VehiclePositionHandler(fetchData usecases.MbtaApiHelper) http.HandlerFunc {
 		json.NewEncoder(w).Encode(vehicles)
 	}
 }
+
+// writeVehiclePositionsFeed writes the vehicles as a GTFS-Realtime VehiclePositions feed
+// in the binary or text format.
+func writeVehiclePositionsFeed(w http.ResponseWriter, vehicles []models.Vehicle, format vehicleFormat) {
+	feed := gtfsrt.NewVehiclePositionsFeed(vehicles, time.Now())
+
+	marshal, contentType := gtfsrt.Marshal, contentTypeProtobuf
+	if format == formatGTFSRTText {
+		marshal, contentType = gtfsrt.MarshalText, contentTypeText
+	}
+
+	data, err := marshal(feed)
+	if err != nil {
+		log.Println("Error encoding GTFS-Realtime feed:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(data)
+}
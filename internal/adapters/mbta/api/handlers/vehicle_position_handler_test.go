@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"explorer/internal/adapters/distribute"
+	"explorer/internal/adapters/store"
+	"explorer/internal/core/domain/models"
+	"explorer/internal/core/usecases"
+	ports "explorer/internal/ports/streaming"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"google.golang.org/protobuf/proto"
+)
+
+// liveUseCases serves a single Red Line vehicle as the live data of any route
+type liveUseCases struct {
+	usecases.MbtaApiHelper // Other calls are not used
+}
+
+func (liveUseCases) GetLiveData(ctx context.Context, routeID string, routeTypes []int) ([]models.Vehicle, error) {
+	return []models.Vehicle{{ID: "R-5463D2B1", Route: "Red", Attributes: models.VehicleAttributes{Latitude: 42.39674}}}, nil
+}
+
+func TestVehiclePositionHandlerServesGTFSRealtime(t *testing.T) {
+	// No stream is running, so the vehicles are fetched from the use cases
+	registry := usecases.NewStreamRegistryUseCase(func() (ports.StreamSource, ports.StreamDistributor, ports.StreamStore) {
+		t.Fatal("the handler started a stream")
+		return nil, distribute.NewClientDistributor(distribute.DefaultDistributorOptions()), store.NewStreamStore()
+	}, time.Second)
+	handler := VehiclePositionHandler(liveUseCases{}, registry)
+
+	tests := []struct {
+		name        string
+		query       string
+		accept      string
+		contentType string
+	}{
+		{name: "format parameter", query: "format=gtfs-rt", contentType: contentTypeProtobuf},
+		{name: "Accept header", accept: "application/vnd.google.protobuf", contentType: contentTypeProtobuf},
+		{name: "text format", query: "format=gtfs-rt-text", contentType: contentTypeText},
+		{name: "JSON by default", contentType: "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/api/vehicles?route_ids=Red&"+tt.query, nil)
+			if tt.accept != "" {
+				request.Header.Set("Accept", tt.accept)
+			}
+			recorder := httptest.NewRecorder()
+			handler(recorder, request)
+
+			if contentType := recorder.Header().Get("Content-Type"); contentType != tt.contentType {
+				t.Fatalf("Content-Type = %q, want %q", contentType, tt.contentType)
+			}
+			if tt.contentType != contentTypeProtobuf {
+				return
+			}
+			feed := &gtfs.FeedMessage{}
+			if err := proto.Unmarshal(recorder.Body.Bytes(), feed); err != nil {
+				t.Fatalf("body does not decode as a FeedMessage: %v", err)
+			}
+			if len(feed.GetEntity()) != 1 || feed.GetEntity()[0].GetId() != "R-5463D2B1" {
+				t.Errorf("feed entities = %v, want the Red Line vehicle", feed.GetEntity())
+			}
+		})
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/api/vehicles?route_ids=Red&format=xml", nil)
+	recorder := httptest.NewRecorder()
+	handler(recorder, request)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("unsupported format: status %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+}
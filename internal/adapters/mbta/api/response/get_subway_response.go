@@ -4,6 +4,9 @@ import "explorer/internal/core/domain/models"
 
 type GetRouteResponse struct {
 	ID          string        `json:"id"`
+	Type        *int          `json:"type,omitempty"` // GTFS route type, if known
+	Mode        string        `json:"mode,omitempty"` // Name of the route type, e.g. "subway" or "bus"
 	Coordinates [][][]float64 `json:"coordinates"`
 	Stops       []models.Stop `json:"stops"`
+	Error       string        `json:"error,omitempty"` // Why the stops or shapes could not be fetched, leaving them empty
 }
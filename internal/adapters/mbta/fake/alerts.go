@@ -0,0 +1,122 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultAlertActivities are the activities alerts are filtered by when filter[activity] is not set, as in the MBTA API.
+var defaultAlertActivities = []string{"BOARD", "EXIT", "RIDE"}
+
+// informedEntity is what an alert fixture applies to, with the fields needed for filtering.
+type informedEntity struct {
+	Activities []string `json:"activities"`
+	Route      string   `json:"route"`
+	Stop       string   `json:"stop"`
+}
+
+// handleAlerts serves the alert fixtures, either as a JSON:API document or, when the client asks
+// for text/event-stream, as a live stream of reset and update events. Every fixture is treated as
+// active, so filter[datetime] is ignored.
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	alerts := s.filterAlerts(r)
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		data := []json.RawMessage{}
+		for _, alert := range alerts {
+			data = append(data, alert.raw)
+		}
+		writeData(w, data)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	// Like the real API, start with every matching alert.
+	reset := []json.RawMessage{}
+	for _, alert := range alerts {
+		reset = append(reset, alert.raw)
+	}
+	writeEvent(w, "reset", reset)
+	flusher.Flush()
+
+	updates := time.NewTicker(s.interval)
+	defer updates.Stop()
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-updates.C:
+			if len(alerts) > 0 {
+				writeEvent(w, "update", touchAlert(alerts[rand.Intn(len(alerts))]))
+				flusher.Flush()
+			}
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// filterAlerts returns the alert fixtures matching the request's filter[id] and filter[severity], with
+// an informed entity matching filter[route], filter[stop] and filter[activity] together.
+func (s *Server) filterAlerts(r *http.Request) []resource {
+	ids := filterValues(r, "id")
+	severities := filterValues(r, "severity")
+	routeIDs := filterValues(r, "route")
+	stopIDs := filterValues(r, "stop")
+	activities := filterValues(r, "activity")
+	if len(activities) == 0 {
+		activities = defaultAlertActivities
+	}
+
+	var matching []resource
+	for _, alert := range s.alerts {
+		if !matches(ids, alert.ID) || !matches(severities, strconv.Itoa(alert.Attributes.Severity)) {
+			continue
+		}
+		for _, entity := range alert.Attributes.InformedEntity {
+			if matches(routeIDs, entity.Route) && matches(stopIDs, entity.Stop) && matchesActivity(activities, entity.Activities) {
+				matching = append(matching, alert)
+				break
+			}
+		}
+	}
+	return matching
+}
+
+// matchesActivity reports whether any of an informed entity's activities is wanted. ALL matches any activity.
+func matchesActivity(wanted []string, activities []string) bool {
+	for _, activity := range activities {
+		if matches(wanted, activity) || matches(wanted, "ALL") {
+			return true
+		}
+	}
+	return false
+}
+
+// touchAlert returns a copy of the alert marked as updated now, as if its text had been revised.
+func touchAlert(alert resource) map[string]any {
+	var copied map[string]any
+	json.Unmarshal(alert.raw, &copied)
+
+	attributes := copied["attributes"].(map[string]any)
+	attributes["updated_at"] = time.Now().Format(time.RFC3339)
+	return copied
+}
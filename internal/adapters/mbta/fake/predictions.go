@@ -0,0 +1,81 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// handlePredictions serves the prediction fixtures, either as a JSON:API document or, when the
+// client asks for text/event-stream, as a live stream of reset and update events.
+func (s *Server) handlePredictions(w http.ResponseWriter, r *http.Request) {
+	predictions := s.filterResources(r, s.predictions, "route")
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		data := []json.RawMessage{}
+		for _, prediction := range predictions {
+			data = append(data, prediction.raw)
+		}
+		writeData(w, data)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	// Like the real API, start with every matching prediction.
+	reset := []json.RawMessage{}
+	for _, prediction := range predictions {
+		reset = append(reset, prediction.raw)
+	}
+	writeEvent(w, "reset", reset)
+	flusher.Flush()
+
+	updates := time.NewTicker(s.interval)
+	defer updates.Stop()
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-updates.C:
+			if len(predictions) > 0 {
+				writeEvent(w, "update", shiftPrediction(predictions[rand.Intn(len(predictions))]))
+				flusher.Flush()
+			}
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// shiftPrediction returns a copy of the prediction with its times moved by up to 30 seconds,
+// as if the vehicle serving it had sped up or slowed down.
+func shiftPrediction(prediction resource) map[string]any {
+	var copied map[string]any
+	json.Unmarshal(prediction.raw, &copied)
+
+	shift := time.Duration(rand.Intn(61)-30) * time.Second
+	attributes := copied["attributes"].(map[string]any)
+	for _, name := range []string{"arrival_time", "departure_time"} {
+		if value, ok := attributes[name].(string); ok {
+			if t, err := time.Parse(time.RFC3339, value); err == nil {
+				attributes[name] = t.Add(shift).Format(time.RFC3339)
+			}
+		}
+	}
+	return copied
+}
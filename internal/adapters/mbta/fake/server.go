@@ -0,0 +1,379 @@
+// Package fake provides an in-process stand-in for the MBTA V3 API, serving fixture data
+// for the endpoints this service uses so it can be run and tested without production access.
+package fake
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+//go:embed fixtures/*.json
+var fixtureFiles embed.FS
+
+// resource is a single JSON:API resource from a fixture, kept as raw JSON so it is served
+// exactly as written while still exposing the fields needed for filtering.
+type resource struct {
+	ID            string                  `json:"id"`
+	Attributes    attributes              `json:"attributes"`
+	Relationships map[string]relationship `json:"relationships"`
+	raw           json.RawMessage
+}
+
+// attributes holds the resource attributes needed for filtering.
+type attributes struct {
+	Type           *int             `json:"type"`            // Route type, only set on routes
+	DirectionID    *int             `json:"direction_id"`    // Only set on trips, schedules and predictions
+	Severity       int              `json:"severity"`        // Only set on alerts
+	InformedEntity []informedEntity `json:"informed_entity"` // Only set on alerts
+}
+
+// relationship is a JSON:API relationship to a single resource.
+type relationship struct {
+	Data *resourceID `json:"data"`
+}
+
+// resourceID identifies a related resource in a JSON:API relationship.
+type resourceID struct {
+	ID string `json:"id"`
+}
+
+// relatedID returns the ID of the named relationship, or an empty string if it is not set.
+func (r resource) relatedID(name string) string {
+	if rel, ok := r.Relationships[name]; ok && rel.Data != nil {
+		return rel.Data.ID
+	}
+	return ""
+}
+
+// Server is a fake MBTA V3 API. It serves /stops (by route or filter[id]), /shapes, /shapes/{id},
+// /routes (with include=line), /trips/{id}, /schedules (with include=trip), /predictions, /alerts and
+// /vehicles from embedded fixtures, honouring filter[route], filter[stop], filter[trip],
+// filter[direction_id] and the route type filters (filter[type] on /routes, filter[route_type] elsewhere), and
+// streams vehicle positions, predictions and alerts as server-sent events when requested with
+// Accept: text/event-stream.
+type Server struct {
+	router      *mux.Router
+	stops       map[string][]json.RawMessage // Stops keyed by route ID
+	shapes      map[string][]json.RawMessage // Shapes keyed by route ID
+	routes      []resource
+	routeTypes  map[string]string          // Route type of every route fixture, keyed by route ID
+	lines       map[string]json.RawMessage // Lines included in /routes responses, keyed by line ID
+	predictions []resource
+	schedules   []resource
+	trips       map[string]json.RawMessage // Trips served by /trips/{id} and included in /schedules responses, keyed by trip ID
+	alerts      []resource
+
+	vehiclesMutex sync.Mutex
+	vehicles      []map[string]any // Current vehicle positions, moved on every stream tick
+	interval      time.Duration    // Time between streamed vehicle updates
+}
+
+// NewServer loads the embedded fixtures and returns a Server ready to handle requests.
+//
+// Parameters:
+// - interval: How often the SSE vehicle stream emits an update event.
+//
+// Returns:
+// - The fake server, or an error if a fixture cannot be decoded.
+func NewServer(interval time.Duration) (*Server, error) {
+	s := &Server{interval: interval}
+
+	if err := loadFixture("stops.json", &s.stops); err != nil {
+		return nil, err
+	}
+	if err := loadFixture("shapes.json", &s.shapes); err != nil {
+		return nil, err
+	}
+	if err := loadFixture("vehicles.json", &s.vehicles); err != nil {
+		return nil, err
+	}
+	var err error
+	if s.routes, err = loadResources("routes.json"); err != nil {
+		return nil, err
+	}
+	if s.predictions, err = loadResources("predictions.json"); err != nil {
+		return nil, err
+	}
+	if s.schedules, err = loadResources("schedules.json"); err != nil {
+		return nil, err
+	}
+	if s.alerts, err = loadResources("alerts.json"); err != nil {
+		return nil, err
+	}
+
+	trips, err := loadResources("trips.json")
+	if err != nil {
+		return nil, err
+	}
+	s.trips = make(map[string]json.RawMessage, len(trips))
+	for _, trip := range trips {
+		s.trips[trip.ID] = trip.raw
+	}
+
+	lines, err := loadResources("lines.json")
+	if err != nil {
+		return nil, err
+	}
+	s.lines = make(map[string]json.RawMessage, len(lines))
+	for _, line := range lines {
+		s.lines[line.ID] = line.raw
+	}
+
+	s.routeTypes = make(map[string]string)
+	for _, route := range s.routes {
+		if route.Attributes.Type != nil {
+			s.routeTypes[route.ID] = strconv.Itoa(*route.Attributes.Type)
+		}
+	}
+
+	s.router = mux.NewRouter()
+	s.router.HandleFunc("/stops", s.handleStops).Methods("GET")
+	s.router.HandleFunc("/shapes", s.handleKeyed(s.shapes)).Methods("GET")
+	s.router.HandleFunc("/shapes/{id}", s.handleShape).Methods("GET")
+	s.router.HandleFunc("/routes", s.handleRoutes).Methods("GET")
+	s.router.HandleFunc("/trips/{id}", s.handleTrip).Methods("GET")
+	s.router.HandleFunc("/schedules", s.handleSchedules).Methods("GET")
+	s.router.HandleFunc("/predictions", s.handlePredictions).Methods("GET")
+	s.router.HandleFunc("/alerts", s.handleAlerts).Methods("GET")
+	s.router.HandleFunc("/vehicles", s.handleVehicles).Methods("GET")
+
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
+}
+
+// loadFixture decodes the named embedded fixture into target.
+func loadFixture(name string, target any) error {
+	data, err := fixtureFiles.ReadFile("fixtures/" + name)
+	if err != nil {
+		return fmt.Errorf("failed to read fixture %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("failed to decode fixture %s: %w", name, err)
+	}
+	return nil
+}
+
+// loadResources decodes the named embedded fixture as a flat list of JSON:API resources.
+func loadResources(name string) ([]resource, error) {
+	var raw []json.RawMessage
+	if err := loadFixture(name, &raw); err != nil {
+		return nil, err
+	}
+
+	resources := make([]resource, len(raw))
+	for i, item := range raw {
+		if err := json.Unmarshal(item, &resources[i]); err != nil {
+			return nil, fmt.Errorf("failed to decode fixture %s: %w", name, err)
+		}
+		resources[i].raw = item
+	}
+	return resources, nil
+}
+
+// filterValues returns the comma-separated values of the filter[name] query parameter.
+func filterValues(r *http.Request, name string) []string {
+	value := r.URL.Query().Get("filter[" + name + "]")
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// handleKeyed serves fixtures keyed by route ID, returning the resources for every route
+// listed in filter[route].
+func (s *Server) handleKeyed(byRoute map[string][]json.RawMessage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := []json.RawMessage{}
+		for _, routeID := range filterValues(r, "route") {
+			data = append(data, byRoute[routeID]...)
+		}
+		writeData(w, data)
+	}
+}
+
+// handleStops serves the stops listed in filter[id] when it is set, and otherwise the stops of
+// every route listed in filter[route].
+func (s *Server) handleStops(w http.ResponseWriter, r *http.Request) {
+	ids := filterValues(r, "id")
+	if ids == nil {
+		s.handleKeyed(s.stops)(w, r)
+		return
+	}
+
+	// Stops are listed once per route serving them, so only return each one once
+	data := []json.RawMessage{}
+	seen := make(map[string]bool)
+	for _, stops := range s.stops {
+		for _, stop := range stops {
+			var id resourceID
+			if json.Unmarshal(stop, &id) == nil && matches(ids, id.ID) && !seen[id.ID] {
+				data = append(data, stop)
+				seen[id.ID] = true
+			}
+		}
+	}
+	writeData(w, data)
+}
+
+// handleRoutes serves the route fixtures matching the request's filters, including the line
+// of every returned route when the request asks for include=line.
+func (s *Server) handleRoutes(w http.ResponseWriter, r *http.Request) {
+	routes := s.filterResources(r, s.routes, "")
+
+	data := []json.RawMessage{}
+	for _, route := range routes {
+		data = append(data, route.raw)
+	}
+	if !matches(strings.Split(r.URL.Query().Get("include"), ","), "line") {
+		writeData(w, data)
+		return
+	}
+
+	included := []json.RawMessage{}
+	seen := make(map[string]bool)
+	for _, route := range routes {
+		lineID := route.relatedID("line")
+		if line, ok := s.lines[lineID]; ok && !seen[lineID] {
+			included = append(included, line)
+			seen[lineID] = true
+		}
+	}
+	writeDocument(w, data, included)
+}
+
+// handleTrip serves the trip fixture with the ID in the path, or a 404 error if there is none.
+func (s *Server) handleTrip(w http.ResponseWriter, r *http.Request) {
+	trip, ok := s.trips[mux.Vars(r)["id"]]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+	writeData(w, trip)
+}
+
+// handleShape serves the shape fixture with the ID in the path, or a 404 error if there is none.
+func (s *Server) handleShape(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	for _, shapes := range s.shapes {
+		for _, shape := range shapes {
+			var shapeID resourceID
+			if json.Unmarshal(shape, &shapeID) == nil && shapeID.ID == id {
+				writeData(w, shape)
+				return
+			}
+		}
+	}
+	writeNotFound(w)
+}
+
+// handleSchedules serves the schedule fixtures matching the request's filters, including the trip
+// of every returned schedule when the request asks for include=trip. Every fixture is treated as
+// scheduled on the requested date, so filter[date] is ignored.
+func (s *Server) handleSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules := s.filterResources(r, s.schedules, "route")
+
+	data := []json.RawMessage{}
+	for _, schedule := range schedules {
+		data = append(data, schedule.raw)
+	}
+	if !matches(strings.Split(r.URL.Query().Get("include"), ","), "trip") {
+		writeData(w, data)
+		return
+	}
+
+	included := []json.RawMessage{}
+	seen := make(map[string]bool)
+	for _, schedule := range schedules {
+		tripID := schedule.relatedID("trip")
+		if trip, ok := s.trips[tripID]; ok && !seen[tripID] {
+			included = append(included, trip)
+			seen[tripID] = true
+		}
+	}
+	writeDocument(w, data, included)
+}
+
+// filterResources returns the resources of a flat fixture list matching the request's filters.
+// Resources are filtered by filter[id], by filter[route] and the route type filters against
+// the routeRelation relationship (or the resource ID when routeRelation is empty), by
+// filter[stop] and filter[trip] against the stop and trip relationships and by filter[direction_id].
+func (s *Server) filterResources(r *http.Request, resources []resource, routeRelation string) []resource {
+	ids := filterValues(r, "id")
+	matchesRoute := s.routeFilter(r)
+	stopIDs := filterValues(r, "stop")
+	tripIDs := filterValues(r, "trip")
+	directions := filterValues(r, "direction_id")
+
+	var matching []resource
+	for _, res := range resources {
+		routeID := res.ID
+		if routeRelation != "" {
+			routeID = res.relatedID(routeRelation)
+		}
+		direction := ""
+		if res.Attributes.DirectionID != nil {
+			direction = strconv.Itoa(*res.Attributes.DirectionID)
+		}
+		if matches(ids, res.ID) && matchesRoute(routeID) && matches(stopIDs, res.relatedID("stop")) &&
+			matches(tripIDs, res.relatedID("trip")) && matches(directions, direction) {
+			matching = append(matching, res)
+		}
+	}
+	return matching
+}
+
+// routeFilter returns a function reporting whether a route ID passes the request's filter[route]
+// and route type filters. The MBTA API calls the route type filter filter[type] on /routes and
+// filter[route_type] on other resources, so both are honoured.
+func (s *Server) routeFilter(r *http.Request) func(routeID string) bool {
+	routeIDs := filterValues(r, "route")
+	routeTypes := append(filterValues(r, "type"), filterValues(r, "route_type")...)
+
+	return func(routeID string) bool {
+		return matches(routeIDs, routeID) && matches(routeTypes, s.routeTypes[routeID])
+	}
+}
+
+// matches reports whether value is in values. An empty filter matches everything.
+func matches(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// writeData writes a JSON:API document with the given primary data.
+func writeData(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+// writeNotFound writes a JSON:API error document for a resource that does not exist.
+func writeNotFound(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]any{"errors": []map[string]string{{"status": "404", "code": "not_found"}}})
+}
+
+// writeDocument writes a JSON:API document with the given primary data and included resources.
+func writeDocument(w http.ResponseWriter, data any, included any) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	json.NewEncoder(w).Encode(map[string]any{"data": data, "included": included})
+}
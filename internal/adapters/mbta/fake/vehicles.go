@@ -0,0 +1,133 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// keepAliveInterval is how often the vehicle stream sends a comment line to keep idle connections open.
+const keepAliveInterval = 15 * time.Second
+
+// handleVehicles serves the vehicle fixtures, either as a JSON:API document or, when the client
+// asks for text/event-stream, as a live stream of reset and update events.
+func (s *Server) handleVehicles(w http.ResponseWriter, r *http.Request) {
+	matchesRoute := s.routeFilter(r)
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		// Only the REST endpoint is filtered by trip, to look up the vehicle serving a trip
+		tripIDs := filterValues(r, "trip")
+		vehicles := []map[string]any{}
+		for _, vehicle := range s.vehicleSnapshot(matchesRoute) {
+			if matches(tripIDs, vehicleRelated(vehicle, "trip")) {
+				vehicles = append(vehicles, vehicle)
+			}
+		}
+		writeData(w, vehicles)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	// Like the real API, start with the full state of every matching vehicle.
+	writeEvent(w, "reset", s.vehicleSnapshot(matchesRoute))
+	flusher.Flush()
+
+	updates := time.NewTicker(s.interval)
+	defer updates.Stop()
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-updates.C:
+			if vehicle, ok := s.moveVehicle(matchesRoute); ok {
+				writeEvent(w, "update", vehicle)
+				flusher.Flush()
+			}
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes a single server-sent event with a JSON payload.
+func writeEvent(w http.ResponseWriter, event string, data any) {
+	payload, _ := json.Marshal(data)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+}
+
+// vehicleSnapshot returns a copy of the current vehicles on the routes accepted by matchesRoute.
+func (s *Server) vehicleSnapshot(matchesRoute func(string) bool) []map[string]any {
+	s.vehiclesMutex.Lock()
+	defer s.vehiclesMutex.Unlock()
+
+	vehicles := []map[string]any{}
+	for _, vehicle := range s.vehicles {
+		if matchesRoute(vehicleRoute(vehicle)) {
+			vehicles = append(vehicles, copyVehicle(vehicle))
+		}
+	}
+	return vehicles
+}
+
+// moveVehicle nudges a random vehicle on the routes accepted by matchesRoute and returns a copy
+// of it. It returns false if no vehicle is on those routes.
+func (s *Server) moveVehicle(matchesRoute func(string) bool) (map[string]any, bool) {
+	s.vehiclesMutex.Lock()
+	defer s.vehiclesMutex.Unlock()
+
+	var candidates []map[string]any
+	for _, vehicle := range s.vehicles {
+		if matchesRoute(vehicleRoute(vehicle)) {
+			candidates = append(candidates, vehicle)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, false
+	}
+
+	vehicle := candidates[rand.Intn(len(candidates))]
+	attributes := vehicle["attributes"].(map[string]any)
+	attributes["latitude"] = attributes["latitude"].(float64) + (rand.Float64()-0.5)*0.001
+	attributes["longitude"] = attributes["longitude"].(float64) + (rand.Float64()-0.5)*0.001
+	attributes["updated_at"] = time.Now().Format(time.RFC3339)
+
+	return copyVehicle(vehicle), true
+}
+
+// vehicleRoute returns the route ID from a vehicle's relationships.
+func vehicleRoute(vehicle map[string]any) string {
+	return vehicleRelated(vehicle, "route")
+}
+
+// vehicleRelated returns the ID of the named relationship of a vehicle, or an empty string if it is not set.
+func vehicleRelated(vehicle map[string]any, name string) string {
+	relationships, _ := vehicle["relationships"].(map[string]any)
+	related, _ := relationships[name].(map[string]any)
+	data, _ := related["data"].(map[string]any)
+	id, _ := data["id"].(string)
+	return id
+}
+
+// copyVehicle returns a deep copy of vehicle, so it can be encoded outside the lock.
+func copyVehicle(vehicle map[string]any) map[string]any {
+	data, _ := json.Marshal(vehicle)
+	var copied map[string]any
+	json.Unmarshal(data, &copied)
+	return copied
+}
@@ -0,0 +1,194 @@
+package mbta
+
+import (
+	"context"
+	"encoding/json"
+	"explorer/internal/core/domain/models"
+	"fmt"
+	"net/url"
+	"path"
+)
+
+// Resources streamed by the MBTA API, named after the path of their stream
+const (
+	resourceVehicles    = "vehicles"
+	resourcePredictions = "predictions"
+	resourceAlerts      = "alerts"
+)
+
+// streamResource returns the kind of resource carried by the stream at url, e.g. "predictions"
+// for .../predictions?filter[stop]=place-pktrm. Streams of unknown resources are read as vehicles.
+func streamResource(streamURL string) string {
+	u, err := url.Parse(streamURL)
+	if err != nil {
+		return resourceVehicles
+	}
+	switch base := path.Base(u.Path); base {
+	case resourcePredictions, resourceAlerts:
+		return base
+	}
+	return resourceVehicles
+}
+
+// decodeEvent decodes the payload of an upstream SSE event into a typed stream event for
+// the kind of resource carried by the stream.
+//
+// Parameters:
+// - ctx: The context of the stream, passed on to the route type and alert resolvers.
+// - resource: The kind of resource carried by the stream, see streamResource.
+// - eventType: The SSE event name ("reset", "add", "update" or "remove").
+// - data: The JSON:API payload carried by the event.
+//
+// Returns:
+// - The unnumbered stream event, or an error if the event is unknown or its payload cannot be decoded.
+func (m *MBTAStreamSource) decodeEvent(ctx context.Context, resource, eventType, data string) (models.StreamEvent, error) {
+	switch resource {
+	case resourcePredictions:
+		return decodePredictionEvent(eventType, data)
+	case resourceAlerts:
+		return m.decodeAlertEvent(ctx, eventType, data)
+	}
+	return m.decodeVehicleEvent(ctx, eventType, data)
+}
+
+// decodeVehicleEvent decodes the payload of a vehicle stream event, filling in the derived
+// route fields of the vehicles it carries.
+func (m *MBTAStreamSource) decodeVehicleEvent(ctx context.Context, eventType, data string) (models.StreamEvent, error) {
+	switch eventType {
+	case models.ResetEvent:
+		// A reset carries the full list of vehicles and replaces the current state.
+		var vehicles []models.Vehicle
+		if err := json.Unmarshal([]byte(data), &vehicles); err != nil {
+			return nil, fmt.Errorf("error decoding reset event: %w", err)
+		}
+		for i := range vehicles {
+			m.populateVehicle(ctx, &vehicles[i])
+		}
+		return models.VehicleReset{Vehicles: vehicles}, nil
+
+	case models.AddedEvent, models.UpdatedEvent:
+		// Add and update both carry a single, complete vehicle resource.
+		var vehicle models.Vehicle
+		if err := json.Unmarshal([]byte(data), &vehicle); err != nil {
+			return nil, fmt.Errorf("error decoding %s event: %w", eventType, err)
+		}
+		m.populateVehicle(ctx, &vehicle)
+		if eventType == models.AddedEvent {
+			return models.VehicleAdded{Vehicle: vehicle}, nil
+		}
+		return models.VehicleUpdated{Vehicle: vehicle}, nil
+
+	case models.RemovedEvent:
+		// A remove only carries the resource identifier.
+		var identifier models.RouteData
+		if err := json.Unmarshal([]byte(data), &identifier); err != nil {
+			return nil, fmt.Errorf("error decoding remove event: %w", err)
+		}
+		return models.VehicleRemoved{VehicleID: identifier.ID}, nil
+	}
+
+	return nil, fmt.Errorf("unknown event %q", eventType)
+}
+
+// decodePredictionEvent decodes the payload of a prediction stream event, flattening the
+// prediction resources it carries.
+func decodePredictionEvent(eventType, data string) (models.StreamEvent, error) {
+	switch eventType {
+	case models.ResetEvent:
+		var resources []models.PredictionResource
+		if err := json.Unmarshal([]byte(data), &resources); err != nil {
+			return nil, fmt.Errorf("error decoding reset event: %w", err)
+		}
+		predictions := make([]models.Prediction, len(resources))
+		for i, resource := range resources {
+			predictions[i] = resource.Prediction()
+		}
+		return models.PredictionReset{Predictions: predictions}, nil
+
+	case models.AddedEvent, models.UpdatedEvent:
+		var resource models.PredictionResource
+		if err := json.Unmarshal([]byte(data), &resource); err != nil {
+			return nil, fmt.Errorf("error decoding %s event: %w", eventType, err)
+		}
+		if eventType == models.AddedEvent {
+			return models.PredictionAdded{Prediction: resource.Prediction()}, nil
+		}
+		return models.PredictionUpdated{Prediction: resource.Prediction()}, nil
+
+	case models.RemovedEvent:
+		var identifier models.RouteData
+		if err := json.Unmarshal([]byte(data), &identifier); err != nil {
+			return nil, fmt.Errorf("error decoding remove event: %w", err)
+		}
+		return models.PredictionRemoved{PredictionID: identifier.ID}, nil
+	}
+
+	return nil, fmt.Errorf("unknown event %q", eventType)
+}
+
+// decodeAlertEvent decodes the payload of an alert stream event, flattening the alert resources
+// it carries and resolving the routes and stops they affect.
+func (m *MBTAStreamSource) decodeAlertEvent(ctx context.Context, eventType, data string) (models.StreamEvent, error) {
+	switch eventType {
+	case models.ResetEvent:
+		var resources []models.AlertResource
+		if err := json.Unmarshal([]byte(data), &resources); err != nil {
+			return nil, fmt.Errorf("error decoding reset event: %w", err)
+		}
+		alerts := make([]models.Alert, len(resources))
+		for i, resource := range resources {
+			alerts[i] = resource.Alert()
+		}
+		m.resolveAlerts(ctx, alerts)
+		return models.AlertReset{Alerts: alerts}, nil
+
+	case models.AddedEvent, models.UpdatedEvent:
+		var resource models.AlertResource
+		if err := json.Unmarshal([]byte(data), &resource); err != nil {
+			return nil, fmt.Errorf("error decoding %s event: %w", eventType, err)
+		}
+		alerts := []models.Alert{resource.Alert()}
+		m.resolveAlerts(ctx, alerts)
+		if eventType == models.AddedEvent {
+			return models.AlertAdded{Alert: alerts[0]}, nil
+		}
+		return models.AlertUpdated{Alert: alerts[0]}, nil
+
+	case models.RemovedEvent:
+		var identifier models.RouteData
+		if err := json.Unmarshal([]byte(data), &identifier); err != nil {
+			return nil, fmt.Errorf("error decoding remove event: %w", err)
+		}
+		return models.AlertRemoved{AlertID: identifier.ID}, nil
+	}
+
+	return nil, fmt.Errorf("unknown event %q", eventType)
+}
+
+// resolveAlerts fills in the routes, stops and modes affected by decoded alerts, when an alert
+// resolver is configured.
+func (m *MBTAStreamSource) resolveAlerts(ctx context.Context, alerts []models.Alert) {
+	if m.options.Alerts != nil {
+		m.options.Alerts.ResolveAlerts(ctx, alerts)
+	}
+}
+
+// populateVehicle fills in the route ID and, when a route type resolver is configured,
+// the route type and mode of a decoded vehicle.
+func (m *MBTAStreamSource) populateVehicle(ctx context.Context, vehicle *models.Vehicle) {
+	populateRoute(vehicle)
+	if m.options.RouteTypes == nil || vehicle.Route == "" {
+		return
+	}
+	if routeType, ok := m.options.RouteTypes.RouteType(ctx, vehicle.Route); ok {
+		vehicle.SetRouteType(routeType)
+	}
+}
+
+// populateRoute copies the route ID from the vehicle's relationships onto the Route field,
+// matching the shape returned by the REST vehicle endpoint.
+func populateRoute(vehicle *models.Vehicle) {
+	if vehicle.Relationships != nil && vehicle.Relationships.Route.Data.ID != "" {
+		vehicle.Route = vehicle.Relationships.Route.Data.ID
+	}
+}
@@ -0,0 +1,53 @@
+package mbta
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// backoff computes retry delays that grow exponentially from initial up to max,
+// with equal jitter so many instances do not reconnect in lockstep.
+type backoff struct {
+	initial time.Duration
+	max     time.Duration
+	attempt int // Number of consecutive failed attempts
+}
+
+// next returns the delay before the next attempt and records the attempt.
+func (b *backoff) next() time.Duration {
+	ceiling := b.max
+	if b.attempt < 30 { // Avoid overflowing the shift below
+		if d := b.initial << b.attempt; d > 0 && d < b.max {
+			ceiling = d
+		}
+	}
+	b.attempt++
+
+	// Equal jitter: pick a random delay between half the ceiling and the ceiling, so retries are never immediate.
+	half := ceiling / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// reset starts the delays over after a healthy connection.
+func (b *backoff) reset() {
+	b.attempt = 0
+}
+
+// parseRetryAfter reads a Retry-After header, given either in seconds or as an HTTP date.
+// It returns 0 if the header is missing or invalid.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,59 @@
+package mbta
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoffNext(t *testing.T) {
+	retry := &backoff{initial: time.Second, max: 10 * time.Second}
+
+	// The ceiling doubles with every attempt up to the maximum, with a delay of at least half of it
+	ceilings := []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second,
+	}
+	for attempt, ceiling := range ceilings {
+		if delay := retry.next(); delay < ceiling/2 || delay > ceiling {
+			t.Errorf("attempt %d: delay %s, want between %s and %s", attempt, delay, ceiling/2, ceiling)
+		}
+	}
+
+	// Many attempts later the shift does not overflow past the maximum
+	for range 100 {
+		if delay := retry.next(); delay < 5*time.Second || delay > 10*time.Second {
+			t.Fatalf("attempt %d: delay %s, want between 5s and 10s", retry.attempt, delay)
+		}
+	}
+
+	// A reset starts the delays over
+	retry.reset()
+	if delay := retry.next(); delay > time.Second {
+		t.Errorf("delay after reset = %s, want at most 1s", delay)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{name: "missing", value: "", min: 0, max: 0},
+		{name: "seconds", value: "30", min: 30 * time.Second, max: 30 * time.Second},
+		{name: "zero seconds", value: "0", min: 0, max: 0},
+		{name: "negative seconds", value: "-5", min: 0, max: 0},
+		{name: "HTTP date", value: time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), min: 58 * time.Second, max: time.Minute},
+		{name: "past HTTP date", value: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), min: 0, max: 0},
+		{name: "invalid", value: "soon", min: 0, max: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+				t.Errorf("parseRetryAfter(%q) = %s, want between %s and %s", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
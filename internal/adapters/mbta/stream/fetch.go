@@ -5,8 +5,24 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 )
 
+// statusError is returned when the MBTA API answers the stream request with a non-200 status.
+type statusError struct {
+	StatusCode int
+	RetryAfter time.Duration // Delay requested by the server via Retry-After, if any
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// isAuthFailure reports whether retrying cannot succeed without a new API key.
+func (e *statusError) isAuthFailure() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
 // fetchStream handles the HTTP request logic to establish a stream connection
 // to the specified URL and returns the response body for further processing.
 //
@@ -17,7 +33,7 @@ import (
 //
 // Returns:
 // - io.ReadCloser: The response body for reading the stream data.
-// - error: An error if the request fails or the response status is not OK.
+// - error: An error if the request fails, or a *statusError if the response status is not OK.
 func (m *MBTAStreamSource) fetchStream(ctx context.Context, url, apiKey string) (io.ReadCloser, error) {
 	// Create a new HTTP GET request with the provided context, URL, and API key.
 	req, err := m.createRequest(ctx, url, apiKey)
@@ -35,7 +51,10 @@ func (m *MBTAStreamSource) fetchStream(ctx context.Context, url, apiKey string)
 	// Verify that the response status code indicates success (200 OK).
 	if resp.StatusCode != http.StatusOK {
 		resp.Body.Close() // Close the response body to avoid resource leaks
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, &statusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
 	}
 
 	// Return the response body for reading the stream.
@@ -1,48 +1,45 @@
 package mbta
 
 import (
-	"fmt"
-	"strings"
+	"context"
+	"explorer/internal/pkg"
+	"log"
 )
 
-// processSSE parses a Server-Sent Events (SSE) message, extracts its fields,
-// formats it into an SSE-compliant message, and broadcasts it to connected clients.
+// processSSE parses a Server-Sent Events (SSE) message into a typed stream event
+// and broadcasts it to connected clients.
 //
 // Parameters:
+// - ctx: The context of the stream, cancelling lookups made while decoding once the stream stops.
+// - resource: The kind of resource carried by the stream, see streamResource.
 // - event: The raw SSE event string received from the server.
 //
 // Functionality:
-// - Splits the raw event string into lines to parse individual fields.
-// - Extracts the "event" and "data" fields from the message.
-// - Formats the parsed fields into an SSE-compliant message.
-// - Broadcasts the formatted message to all connected clients via the distributor.
-func (m *MBTAStreamSource) processSSE(event string) {
-	// Split the raw event string into lines for processing.
-	lines := strings.Split(event, "\n")
+// - Extracts the "event" and "data" fields from the message and counts the event.
+// - Decodes the payload into a reset, added, updated or removed event of the resource.
+// - Applies the event to the stream store so the current state is always known.
+// - Assigns the next monotonically increasing event ID.
+// - Broadcasts the event to all connected clients via the distributor, which leaves
+// serialization to each client's transport.
+func (m *MBTAStreamSource) processSSE(ctx context.Context, resource, event string) {
+	// Extract the event type and the combined data lines from the raw event.
+	parsed := pkg.ParseSSE(event)
 
-	var eventType string   // Holds the extracted "event" field value.
-	var eventData []string // Accumulates "data" field values.
-
-	// Process each line to extract relevant SSE fields.
-	for _, line := range lines {
-		if strings.HasPrefix(line, "event:") {
-			// Extract and trim the value of the "event" field.
-			eventType = strings.TrimSpace(line[len("event:"):])
-		} else if strings.HasPrefix(line, "data:") {
-			// Extract and trim the value of the "data" field and add it to eventData.
-			eventData = append(eventData, strings.TrimSpace(line[len("data:"):]))
-		}
+	// Keep-alives and other events without data carry nothing to process.
+	if parsed.Data == "" {
+		return
 	}
+	m.stats.recordEvent(parsed.Event)
 
-	// Combine all data lines into a single string, separated by newline characters.
-	fullData := strings.Join(eventData, "\n")
+	streamEvent, err := m.decodeEvent(ctx, resource, parsed.Event, parsed.Data)
+	if err != nil {
+		log.Printf("Failed to decode %s event: %v", parsed.Event, err)
+		return
+	}
 
-	// If data exists, format and broadcast the SSE-compliant message.
-	if fullData != "" {
-		// Format the SSE message with the event type and data.
-		formattedEvent := fmt.Sprintf("event: %s\ndata: %s\n\n", eventType, fullData)
+	// Update the stream store before clients see the event, so snapshots are never behind.
+	m.store.Apply(streamEvent)
 
-		// Broadcast the formatted message to all connected clients.
-		m.distributor.Broadcast(formattedEvent)
-	}
+	// Number the event so clients can resume from it with Last-Event-ID, then broadcast it.
+	m.distributor.Broadcast(streamEvent.WithID(m.lastEventID.Add(1)))
 }
@@ -0,0 +1,56 @@
+package mbta
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// RecordedEvent is a raw upstream SSE event as written by a Recorder, one JSON object per line.
+type RecordedEvent struct {
+	At    time.Time `json:"at"`    // When the event arrived
+	URL   string    `json:"url"`   // The upstream stream the event came from
+	Event string    `json:"event"` // The raw SSE event, without the trailing blank line
+}
+
+// Recorder writes every raw upstream event, with its arrival time, so the stream can be
+// replayed later by a ReplayStreamSource. It is safe for concurrent use by several sources.
+type Recorder struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+}
+
+// NewRecorder initializes a Recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{encoder: json.NewEncoder(w)}
+}
+
+// NewFileRecorder initializes a Recorder appending to the file at path, creating it if needed.
+func NewFileRecorder(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording file: %w", err)
+	}
+	recorder := NewRecorder(file)
+	recorder.closer = file
+	return recorder, nil
+}
+
+// Record writes a raw event received from the stream at url.
+func (r *Recorder) Record(url, event string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.encoder.Encode(RecordedEvent{At: time.Now(), URL: url, Event: event})
+}
+
+// Close closes the underlying file, if the Recorder opened one.
+func (r *Recorder) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
@@ -0,0 +1,142 @@
+package mbta
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"explorer/internal/ports/data"
+	ports "explorer/internal/ports/streaming"
+)
+
+// ReplaySpeed controls how fast a ReplayStreamSource plays back recorded events.
+type ReplaySpeed struct {
+	Factor float64       // Playback speed relative to the recording, e.g. 1 for real time or 10 for 10x
+	Step   time.Duration // If set, play one event per Step, ignoring the recorded timing
+}
+
+// ParseReplaySpeed parses a replay speed such as "1" (real time), "10" (ten times faster)
+// or "500ms" (one event every 500 milliseconds). An empty value means real time.
+func ParseReplaySpeed(value string) (ReplaySpeed, error) {
+	if value == "" {
+		return ReplaySpeed{Factor: 1}, nil
+	}
+	if factor, err := strconv.ParseFloat(value, 64); err == nil && factor > 0 {
+		return ReplaySpeed{Factor: factor}, nil
+	}
+	if step, err := time.ParseDuration(value); err == nil && step > 0 {
+		return ReplaySpeed{Step: step}, nil
+	}
+	return ReplaySpeed{}, fmt.Errorf("invalid replay speed %q, expected a factor like 10 or a step like 500ms", value)
+}
+
+// ReplayStreamSource is a ports.StreamSource that plays back a file written by a Recorder
+// instead of connecting to the MBTA API. Events go through the same processing as live
+// events, so the vehicle store, distributor and event IDs behave exactly as in production.
+type ReplayStreamSource struct {
+	*MBTAStreamSource
+	path  string      // Recording to play back
+	speed ReplaySpeed // Playback speed
+	loop  bool        // Start over at the end of the recording
+}
+
+// NewReplayStreamSource initializes a ReplayStreamSource playing the recording at path.
+// If routeTypes is set, it fills in the route type and mode of the replayed vehicles, and if
+// alerts is set, the routes, stops and modes affected by the replayed alerts.
+func NewReplayStreamSource(distributor ports.StreamDistributor, store ports.StreamStore, routeTypes data.RouteTypeResolver, alerts data.AlertResolver, path string, speed ReplaySpeed, loop bool) *ReplayStreamSource {
+	options := DefaultSourceOptions()
+	options.RouteTypes = routeTypes
+	options.Alerts = alerts
+
+	return &ReplayStreamSource{
+		MBTAStreamSource: NewMBTAStreamSource(distributor, store, options),
+		path:             path,
+		speed:            speed,
+		loop:             loop,
+	}
+}
+
+// Start plays back the recorded events for url until the recording ends or the context is cancelled.
+// Events recorded for other streams are skipped. The API key is not used. The returned channel is closed
+// once playback has ended.
+func (r *ReplayStreamSource) Start(ctx context.Context, url, apiKey string) <-chan struct{} {
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		defer r.setState(ports.StateStopped)
+
+		for {
+			r.setState(ports.StateConnected)
+			if err := r.play(ctx, url); err != nil {
+				log.Printf("Failed to replay %s: %v", r.path, err)
+				r.stats.recordError(err)
+				r.setState(ports.StateFailed)
+				return
+			}
+			if !r.loop || ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+	return stopped
+}
+
+// play reads the recording once, processing every event recorded for url at the configured speed.
+func (r *ReplayStreamSource) play(ctx context.Context, url string) error {
+	file, err := os.Open(r.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Reset events can be large, so allow long lines.
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+
+	var previous time.Time          // Arrival time of the previous event played
+	resource := streamResource(url) // Kind of resource carried by the stream, to decode its events
+	for scanner.Scan() {
+		var recorded RecordedEvent
+		if err := json.Unmarshal(scanner.Bytes(), &recorded); err != nil {
+			return fmt.Errorf("invalid recording line: %w", err)
+		}
+		if !sameStream(recorded.URL, url) {
+			continue
+		}
+
+		// Wait according to the recorded timing, or the fixed step.
+		delay := r.speed.Step
+		if delay == 0 && !previous.IsZero() {
+			delay = time.Duration(float64(recorded.At.Sub(previous)) / r.speed.Factor)
+		}
+		previous = recorded.At
+		if !sleepContext(ctx, delay) {
+			return nil
+		}
+
+		r.stats.bytesReceived.Add(uint64(len(recorded.Event)))
+		r.processSSE(ctx, resource, recorded.Event)
+	}
+	return scanner.Err()
+}
+
+// sameStream reports whether two stream URLs name the same stream, however their query is escaped or
+// ordered, so recordings made before stream URLs were escaped still play.
+func sameStream(a, b string) bool {
+	if a == b {
+		return true
+	}
+	urlA, errA := url.Parse(a)
+	urlB, errB := url.Parse(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return urlA.Scheme == urlB.Scheme && urlA.Host == urlB.Host && urlA.Path == urlB.Path &&
+		urlA.Query().Encode() == urlB.Query().Encode()
+}
@@ -0,0 +1,211 @@
+package mbta
+
+import (
+	"bytes"
+	"context"
+	"explorer/internal/adapters/distribute"
+	"explorer/internal/adapters/store"
+	"explorer/internal/core/domain/models"
+	ports "explorer/internal/ports/streaming"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	recordingPath    = "testdata/vehicles.jsonl" // Recording of the Red Line stream, with one bus event
+	recordedDuration = 9 * time.Second           // Time between the first and last Red Line events
+)
+
+// replayedEvents are the events broadcast when the recording is played back once, in order
+var replayedEvents = []string{models.ResetEvent, models.AddedEvent, models.UpdatedEvent, models.RemovedEvent}
+
+// replayedLatitudes are the latitudes of the vehicles left in the store after playing the recording once, by ID
+var replayedLatitudes = map[string]float64{"R-5482A1B0": 42.15, "R-5482A2D9": 42.3}
+
+// startReplay plays the recording for the Red Line stream back into a new store, returning the store
+// and a client receiving every event broadcast.
+func startReplay(t *testing.T, ctx context.Context, path string, speed ReplaySpeed, loop bool) (*store.StreamStore, chan models.StreamEvent) {
+	t.Helper()
+	streamStore := store.NewStreamStore()
+	distributor := distribute.NewClientDistributor(distribute.DefaultDistributorOptions())
+	client := make(chan models.StreamEvent, 100)
+	distributor.AddClient(client, ports.ClientOptions{})
+
+	source := NewReplayStreamSource(distributor, streamStore, nil, nil, path, speed, loop)
+	source.Start(ctx, vehicleStreamURL, "")
+	return streamStore, client
+}
+
+// receiveEvents waits for n events from the client, failing the test if they do not arrive in time.
+func receiveEvents(t *testing.T, client chan models.StreamEvent, n int) []models.StreamEvent {
+	t.Helper()
+	var events []models.StreamEvent
+	timeout := time.After(5 * time.Second)
+	for len(events) < n {
+		select {
+		case event := <-client:
+			events = append(events, event)
+		case <-timeout:
+			t.Fatalf("received %d events, want %d", len(events), n)
+		}
+	}
+	return events
+}
+
+// checkVehicles fails the test unless the store holds exactly the vehicles with the given latitudes.
+func checkVehicles(t *testing.T, streamStore *store.StreamStore, want map[string]float64) {
+	t.Helper()
+	vehicles, loaded := streamStore.Vehicles()
+	if !loaded {
+		t.Fatal("store not loaded")
+	}
+	if len(vehicles) != len(want) {
+		t.Fatalf("got %d vehicles, want %d: %+v", len(vehicles), len(want), vehicles)
+	}
+	for _, vehicle := range vehicles {
+		if latitude, ok := want[vehicle.ID]; !ok || vehicle.Attributes.Latitude != latitude {
+			t.Errorf("unexpected vehicle %s at latitude %v", vehicle.ID, vehicle.Attributes.Latitude)
+		}
+	}
+}
+
+func TestReplayStreamSourcePlaysRecording(t *testing.T) {
+	tests := []struct {
+		name       string
+		speed      ReplaySpeed
+		minElapsed time.Duration // Least time the playback can take at this speed
+	}{
+		{name: "accelerated", speed: ReplaySpeed{Factor: 100}, minElapsed: recordedDuration / 100},
+		{name: "stepped", speed: ReplaySpeed{Step: 10 * time.Millisecond}, minElapsed: 4 * 10 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			start := time.Now()
+			streamStore, client := startReplay(t, ctx, recordingPath, tt.speed, false)
+			events := receiveEvents(t, client, len(replayedEvents))
+			if elapsed := time.Since(start); elapsed < tt.minElapsed {
+				t.Errorf("played back in %s, want at least %s", elapsed, tt.minElapsed)
+			}
+
+			// Events of other streams and keep-alives are skipped, the others numbered in order
+			for i, event := range events {
+				if event.EventName() != replayedEvents[i] {
+					t.Errorf("event %d is %q, want %q", i, event.EventName(), replayedEvents[i])
+				}
+				if event.EventID() != uint64(i+1) {
+					t.Errorf("event %d has ID %d, want %d", i, event.EventID(), i+1)
+				}
+			}
+			checkVehicles(t, streamStore, replayedLatitudes)
+
+			select {
+			case event := <-client:
+				t.Errorf("unexpected event after the end of the recording: %+v", event)
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestReplayStreamSourceLoopsWithIncreasingIDs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	streamStore, client := startReplay(t, ctx, recordingPath, ReplaySpeed{Step: time.Millisecond}, true)
+	events := receiveEvents(t, client, 2*len(replayedEvents))
+	cancel()
+
+	// The second pass starts with the recorded reset, numbered after the first pass
+	for i, event := range events {
+		if want := replayedEvents[i%len(replayedEvents)]; event.EventName() != want {
+			t.Errorf("event %d is %q, want %q", i, event.EventName(), want)
+		}
+		if event.EventID() != uint64(i+1) {
+			t.Errorf("event %d has ID %d, want %d", i, event.EventID(), i+1)
+		}
+	}
+	checkVehicles(t, streamStore, replayedLatitudes)
+}
+
+func TestRecordedStreamReplaysToSameState(t *testing.T) {
+	// Scan a live stream, recording its events
+	var recording bytes.Buffer
+	options := DefaultSourceOptions()
+	options.Recorder = NewRecorder(&recording)
+	liveStore := store.NewStreamStore()
+	live := NewMBTAStreamSource(distribute.NewClientDistributor(distribute.DefaultDistributorOptions()), liveStore, options)
+	live.scanStream(context.Background(), vehicleStreamURL, strings.NewReader(strings.Join([]string{
+		sseEvent("reset", "["+vehicleJSON("R-1", 42.1)+","+vehicleJSON("R-2", 42.2)+"]"),
+		": keep-alive\n\n",
+		sseEvent("update", vehicleJSON("R-2", 42.25)),
+		sseEvent("add", vehicleJSON("R-3", 42.3)),
+		sseEvent("remove", removedJSON("R-1")),
+	}, "")))
+
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	if err := os.WriteFile(path, recording.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replaying the recording rebuilds the same vehicles
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	replayStore, client := startReplay(t, ctx, path, ReplaySpeed{Step: time.Millisecond}, false)
+	receiveEvents(t, client, 4)
+
+	want, _ := liveStore.Vehicles()
+	latitudes := make(map[string]float64, len(want))
+	for _, vehicle := range want {
+		latitudes[vehicle.ID] = vehicle.Attributes.Latitude
+	}
+	checkVehicles(t, replayStore, latitudes)
+}
+
+func TestParseReplaySpeed(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    ReplaySpeed
+		wantErr bool
+	}{
+		{value: "", want: ReplaySpeed{Factor: 1}},
+		{value: "10", want: ReplaySpeed{Factor: 10}},
+		{value: "0.5", want: ReplaySpeed{Factor: 0.5}},
+		{value: "500ms", want: ReplaySpeed{Step: 500 * time.Millisecond}},
+		{value: "0", wantErr: true},
+		{value: "-1s", wantErr: true},
+		{value: "fast", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseReplaySpeed(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseReplaySpeed(%q) = %+v, %v, want %+v, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSameStream(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: vehicleStreamURL, b: vehicleStreamURL, want: true},
+		{a: vehicleStreamURL, b: "https://api-v3.mbta.com/vehicles?filter%5Broute%5D=Red", want: true},
+		{a: "https://api-v3.mbta.com/alerts?filter[route]=Red&filter[datetime]=NOW", b: "https://api-v3.mbta.com/alerts?filter%5Bdatetime%5D=NOW&filter%5Broute%5D=Red", want: true},
+		{a: vehicleStreamURL, b: "https://api-v3.mbta.com/vehicles?filter%5Broute%5D=Orange", want: false},
+		{a: vehicleStreamURL, b: "https://api-v3.mbta.com/predictions?filter%5Broute%5D=Red", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := sameStream(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameStream(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -12,21 +12,24 @@ import (
 //
 // Parameters:
 // - ctx: The context to manage cancellation or timeouts.
+// - url: The URL of the stream, used when recording events.
 // - responseBody: The stream to be read, typically the HTTP response body.
 //
 // Functionality:
 // - Reads lines from the stream using a buffered scanner.
 // - Buffers lines for each SSE message until a blank line indicates the end of the event.
+// - Records complete SSE messages when a recorder is configured.
 // - Processes complete SSE messages and handles errors in the stream.
-func (m *MBTAStreamSource) scanStream(ctx context.Context, responseBody io.Reader) {
+func (m *MBTAStreamSource) scanStream(ctx context.Context, url string, responseBody io.Reader) {
 	// Create a buffered scanner to read the response body line by line.
 	scanner := bufio.NewScanner(responseBody)
 
-	// Set a 1 MB buffer size for the scanner to handle large event streams.
-	buffer := make([]byte, 1024*1024)
-	scanner.Buffer(buffer, len(buffer))
+	// Start with a 64 KB buffer, growing up to 16 MB for the reset events of large streams
+	// (e.g., every bus in service).
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
 
-	var eventBuffer []string // Buffer to accumulate lines for a single SSE event.
+	var eventBuffer []string        // Buffer to accumulate lines for a single SSE event.
+	resource := streamResource(url) // Kind of resource carried by the stream, to decode its events.
 
 	// Loop through each line in the stream.
 	for scanner.Scan() {
@@ -41,7 +44,8 @@ func (m *MBTAStreamSource) scanStream(ctx context.Context, responseBody io.Reade
 				// If the buffer has accumulated lines, process the event.
 				if len(eventBuffer) > 0 {
 					fullEvent := strings.Join(eventBuffer, "\n") // Combine buffered lines.
-					m.processSSE(fullEvent)                      // Process the complete SSE event.
+					m.recordEvent(url, fullEvent)                // Record the raw event, if enabled.
+					m.processSSE(ctx, resource, fullEvent)       // Process the complete SSE event.
 					eventBuffer = []string{}                     // Clear the buffer for the next event.
 				}
 				continue // Skip to the next line.
@@ -53,7 +57,18 @@ func (m *MBTAStreamSource) scanStream(ctx context.Context, responseBody io.Reade
 	}
 
 	// Handle errors that may occur while scanning the stream.
-	if err := scanner.Err(); err != nil {
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
 		log.Printf("Error reading stream: %v", err) // Log the error for debugging.
+		m.stats.recordError(err)
+	}
+}
+
+// recordEvent writes a raw event to the configured recorder, if any.
+func (m *MBTAStreamSource) recordEvent(url, event string) {
+	if m.options.Recorder == nil {
+		return
+	}
+	if err := m.options.Recorder.Record(url, event); err != nil {
+		log.Printf("Failed to record stream event: %v", err)
 	}
 }
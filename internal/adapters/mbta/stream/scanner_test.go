@@ -0,0 +1,139 @@
+package mbta
+
+import (
+	"context"
+	"explorer/internal/adapters/distribute"
+	"explorer/internal/adapters/store"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// vehicleStreamURL is the upstream URL the recorded vehicle events are read from
+const vehicleStreamURL = "https://api-v3.mbta.com/vehicles?filter[route]=Red"
+
+// sseEvent formats an SSE message as sent by the MBTA API
+func sseEvent(event, data string) string {
+	return fmt.Sprintf("event: %s\ndata: %s\n\n", event, data)
+}
+
+// vehicleJSON returns a vehicle resource on the Red Line at the given latitude
+func vehicleJSON(id string, latitude float64) string {
+	return fmt.Sprintf(`{"id":%q,"type":"vehicle","attributes":{"latitude":%v,"longitude":-71.119,"direction_id":0},`+
+		`"relationships":{"route":{"data":{"id":"Red","type":"route"}}}}`, id, latitude)
+}
+
+// removedJSON returns the identifier carried by a remove event
+func removedJSON(id string) string {
+	return fmt.Sprintf(`{"id":%q,"type":"vehicle"}`, id)
+}
+
+func TestScanStreamAppliesVehicleEvents(t *testing.T) {
+	tests := []struct {
+		name       string
+		events     []string
+		wantLoaded bool
+		want       map[string]float64 // Latitude of every vehicle expected in the store, by ID
+	}{
+		{
+			name: "reset then add, update and remove",
+			events: []string{
+				sseEvent("reset", "["+vehicleJSON("R-1", 42.1)+","+vehicleJSON("R-2", 42.2)+"]"),
+				sseEvent("add", vehicleJSON("R-3", 42.3)),
+				sseEvent("update", vehicleJSON("R-1", 42.15)),
+				sseEvent("remove", removedJSON("R-2")),
+			},
+			wantLoaded: true,
+			want:       map[string]float64{"R-1": 42.15, "R-3": 42.3},
+		},
+		{
+			name: "second reset replaces every vehicle",
+			events: []string{
+				sseEvent("reset", "["+vehicleJSON("R-1", 42.1)+"]"),
+				sseEvent("add", vehicleJSON("R-2", 42.2)),
+				sseEvent("reset", "["+vehicleJSON("R-4", 42.4)+"]"),
+			},
+			wantLoaded: true,
+			want:       map[string]float64{"R-4": 42.4},
+		},
+		{
+			name:       "empty reset loads an empty vehicle set",
+			events:     []string{sseEvent("reset", "[]")},
+			wantLoaded: true,
+			want:       map[string]float64{},
+		},
+		{
+			name: "events before the first reset do not load the store",
+			events: []string{
+				sseEvent("add", vehicleJSON("R-1", 42.1)),
+				sseEvent("update", vehicleJSON("R-1", 42.15)),
+			},
+			wantLoaded: false,
+		},
+		{
+			name: "update of an unknown vehicle adds it and remove of an unknown vehicle is ignored",
+			events: []string{
+				sseEvent("reset", "[]"),
+				sseEvent("update", vehicleJSON("R-1", 42.1)),
+				sseEvent("remove", removedJSON("R-9")),
+			},
+			wantLoaded: true,
+			want:       map[string]float64{"R-1": 42.1},
+		},
+		{
+			name: "malformed and unknown events are skipped",
+			events: []string{
+				sseEvent("reset", "["+vehicleJSON("R-1", 42.1)+"]"),
+				sseEvent("update", `{"id":`),
+				sseEvent("rename", vehicleJSON("R-1", 0)),
+				sseEvent("update", vehicleJSON("R-1", 42.2)),
+			},
+			wantLoaded: true,
+			want:       map[string]float64{"R-1": 42.2},
+		},
+		{
+			name: "keep-alives are ignored and data split over several lines is joined",
+			events: []string{
+				": keep-alive\n\n",
+				"event: reset\ndata: [" + vehicleJSON("R-1", 42.1) + ",\ndata: " + vehicleJSON("R-2", 42.2) + "]\n\n",
+				": keep-alive\n\n",
+			},
+			wantLoaded: true,
+			want:       map[string]float64{"R-1": 42.1, "R-2": 42.2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vehicleStore := store.NewStreamStore()
+			distributor := distribute.NewClientDistributor(distribute.DefaultDistributorOptions())
+			source := NewMBTAStreamSource(distributor, vehicleStore, DefaultSourceOptions())
+
+			source.scanStream(context.Background(), vehicleStreamURL, strings.NewReader(strings.Join(tt.events, "")))
+
+			vehicles, loaded := vehicleStore.Vehicles()
+			if loaded != tt.wantLoaded {
+				t.Fatalf("loaded = %v, want %v", loaded, tt.wantLoaded)
+			}
+			if len(vehicles) != len(tt.want) {
+				t.Fatalf("got %d vehicles, want %d: %+v", len(vehicles), len(tt.want), vehicles)
+			}
+			for _, vehicle := range vehicles {
+				latitude, ok := tt.want[vehicle.ID]
+				if !ok {
+					t.Errorf("unexpected vehicle %s", vehicle.ID)
+					continue
+				}
+				if vehicle.Attributes.Latitude != latitude {
+					t.Errorf("vehicle %s latitude = %v, want %v", vehicle.ID, vehicle.Attributes.Latitude, latitude)
+				}
+				if vehicle.Route != "Red" {
+					t.Errorf("vehicle %s route = %q, want the route from its relationships", vehicle.ID, vehicle.Route)
+				}
+				if got, ok := vehicleStore.Vehicle(vehicle.ID); !ok || got.Attributes.Latitude != latitude {
+					t.Errorf("Vehicle(%s) = %+v, %v", vehicle.ID, got, ok)
+				}
+			}
+		})
+	}
+}
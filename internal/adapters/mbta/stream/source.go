@@ -2,29 +2,83 @@ package mbta
 
 import (
 	"context"
+	"errors"
+	"explorer/internal/ports/data"
 	ports "explorer/internal/ports/streaming"
+	"fmt"
 	"log"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// SourceOptions configures reconnection behaviour and vehicle and alert enrichment of an MBTAStreamSource.
+type SourceOptions struct {
+	InitialBackoff time.Duration // Delay ceiling for the first retry
+	MaxBackoff     time.Duration // Upper bound for the delay between retries
+	IdleTimeout    time.Duration // Reconnect if no bytes (including keep-alives) arrive for this long
+	Recorder       *Recorder     // If set, every raw upstream event is recorded for later replay
+
+	RouteTypes data.RouteTypeResolver // If set, used to fill in the route type and mode of every vehicle
+	Alerts     data.AlertResolver     // If set, used to fill in the routes, stops and modes affected by every alert
+}
+
+// DefaultSourceOptions returns the options used when none are configured.
+func DefaultSourceOptions() SourceOptions {
+	return SourceOptions{
+		InitialBackoff: time.Second,
+		MaxBackoff:     2 * time.Minute,
+		IdleTimeout:    60 * time.Second,
+	}
+}
+
 type MBTAStreamSource struct {
 	distributor ports.StreamDistributor
+	store       ports.StreamStore // Current state built from stream events
+	options     SourceOptions
+	lastEventID atomic.Uint64 // ID of the last event broadcast, increasing across reconnects
+	stats       sourceStats   // Counters reported by SourceStats
+
+	stateMutex sync.RWMutex
+	state      ports.ConnectionState // Current state of the upstream connection
 }
 
-// NewMBTAStreamSource initializes a new MBTAStreamSource with the given distributor.
-func NewMBTAStreamSource(distributor ports.StreamDistributor) *MBTAStreamSource {
+// NewMBTAStreamSource initializes a new MBTAStreamSource with the given distributor, stream store and options.
+func NewMBTAStreamSource(distributor ports.StreamDistributor, store ports.StreamStore, options SourceOptions) *MBTAStreamSource {
 	return &MBTAStreamSource{
 		distributor: distributor,
+		store:       store,
+		options:     options,
+		state:       ports.StateStopped,
 	}
 }
 
+// State returns the current state of the upstream connection.
+func (m *MBTAStreamSource) State() ports.ConnectionState {
+	m.stateMutex.RLock()
+	defer m.stateMutex.RUnlock()
+	return m.state
+}
+
+// setState records the current state of the upstream connection.
+func (m *MBTAStreamSource) setState(state ports.ConnectionState) {
+	m.stateMutex.Lock()
+	defer m.stateMutex.Unlock()
+	m.state = state
+
+	if state == ports.StateReconnecting {
+		m.stats.reconnects.Add(1)
+	}
+	m.stats.recordConnected(state == ports.StateConnected)
+}
+
 // createRequest creates an HTTP GET request for streaming data from the MBTA API.
 //
 // Parameters:
 // - ctx: The context to manage request lifecycle (e.g., timeouts, cancellations).
 // - url: The endpoint to connect to.
-// - apiKey: The API key for authorization.
+// - apiKey: The API key for authorization, not sent if empty.
 //
 // Returns:
 // - A pointer to the created HTTP request or an error if the request creation fails.
@@ -35,7 +89,9 @@ func (m *MBTAStreamSource) createRequest(ctx context.Context, url, apiKey string
 	}
 	// Set necessary headers for SSE.
 	req.Header.Set("Accept", "text/event-stream") // Specify content type for SSE.
-	req.Header.Set("X-API-Key", apiKey)           // Add API key for authorization.
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey) // Add API key for authorization.
+	}
 	return req, nil
 }
 
@@ -46,42 +102,119 @@ func (m *MBTAStreamSource) createRequest(ctx context.Context, url, apiKey string
 // - url: The endpoint to fetch SSE data from.
 // - apiKey: The API key for authenticating the request.
 //
+// Returns:
+// - A channel closed once the stream goroutine has exited, after which no more events are applied or broadcast.
+//
 // This method:
 // - Continuously attempts to fetch and process the stream unless the context is cancelled.
-// - Implements retries with a delay upon errors to avoid tight retry loops.
-func (m *MBTAStreamSource) Start(ctx context.Context, url, apiKey string) {
+// - Retries with exponential backoff and jitter, honoring Retry-After when the server sends it.
+// - Stops for good on authentication failures (401/403), since retrying cannot succeed.
+// - Reconnects when no bytes, including keep-alives, arrive within the idle timeout.
+// - Connects without an API key if none is set, e.g. to a fake MBTA server, leaving the server to reject it.
+func (m *MBTAStreamSource) Start(ctx context.Context, url, apiKey string) <-chan struct{} {
+	if apiKey == "" {
+		log.Println("MBTA_API_KEY environment variable not set, connecting to the stream without an API key")
+	}
+
+	stopped := make(chan struct{})
 	go func() { // Run the streaming logic in a goroutine.
-		for { // Infinite loop to retry on errors or disconnections.
-			select {
-			case <-ctx.Done(): // Exit loop if context is cancelled.
-				log.Println("Context cancelled, stopping stream")
-				return
-			default:
-				// Fetch the stream from the MBTA API.
-				respBody, err := m.fetchStream(ctx, url, apiKey)
-				if err != nil {
-					log.Printf("Failed to fetch stream: %v", err)
-					time.Sleep(time.Second * 5) // Delay before retrying to avoid tight loops.
-					continue
+		defer close(stopped)
+		retry := &backoff{initial: m.options.InitialBackoff, max: m.options.MaxBackoff}
+		defer func() {
+			if ctx.Err() != nil {
+				m.setState(ports.StateStopped)
+			}
+		}()
+
+		for { // Loop to retry on errors or disconnections.
+			m.setState(ports.StateConnecting)
+
+			// Fetch the stream from the MBTA API.
+			respBody, err := m.fetchStream(ctx, url, apiKey)
+			if err != nil {
+				if ctx.Err() != nil { // Exit loop if context is cancelled.
+					log.Println("Context cancelled, stopping stream")
+					return
+				}
+
+				m.stats.recordError(err)
+				delay := retry.next()
+				var statusErr *statusError
+				if errors.As(err, &statusErr) {
+					if statusErr.isAuthFailure() {
+						log.Printf("Stream authentication failed (%v), not retrying", err)
+						m.setState(ports.StateFailed)
+						return
+					}
+					if statusErr.RetryAfter > delay {
+						delay = statusErr.RetryAfter // Respect the delay requested by the server
+					}
 				}
 
-				// Process the stream in a separate goroutine.
-				processDone := make(chan struct{}) // Channel to signal completion of stream processing.
-				go func() {
-					defer close(processDone)    // Ensure channel closure when processing finishes.
-					defer respBody.Close()      // Ensure response body is closed.
-					m.scanStream(ctx, respBody) // Scan and process the stream.
-				}()
-
-				// Wait for either context cancellation or stream processing to finish.
-				select {
-				case <-ctx.Done(): // Stop processing if context is cancelled.
-					respBody.Close()
+				log.Printf("Failed to fetch stream: %v, retrying in %s", err, delay.Round(time.Millisecond))
+				m.setState(ports.StateReconnecting)
+				if !sleepContext(ctx, delay) {
 					return
-				case <-processDone: // Restart the loop on stream processing completion.
-					log.Println("Stream processing ended, will retry")
 				}
+				continue
+			}
+
+			m.setState(ports.StateConnected)
+			body := newWatchedReader(respBody, &m.stats.bytesReceived)
+
+			// Process the stream in a separate goroutine.
+			processDone := make(chan struct{}) // Channel to signal completion of stream processing.
+			go func() {
+				defer close(processDone)     // Ensure channel closure when processing finishes.
+				defer body.Close()           // Ensure response body is closed.
+				m.scanStream(ctx, url, body) // Scan and process the stream.
+			}()
+
+			// Close the connection if it goes quiet, which ends stream processing.
+			idle := make(chan bool, 1)
+			go func() { idle <- body.watch(m.options.IdleTimeout, processDone) }()
+
+			// Wait for either context cancellation or stream processing to finish.
+			select {
+			case <-ctx.Done(): // Stop processing if context is cancelled.
+				body.Close()
+				<-processDone
+				return
+			case <-processDone: // Restart the loop on stream processing completion.
+			}
+
+			if <-idle {
+				log.Printf("No data received for %s, reconnecting", m.options.IdleTimeout)
+				m.stats.recordError(fmt.Errorf("no data received for %s", m.options.IdleTimeout))
+			} else {
+				log.Println("Stream processing ended, will retry")
+			}
+
+			// A connection that delivered data was healthy, so start the delays over.
+			if body.received.Load() {
+				retry.reset()
+			}
+			m.setState(ports.StateReconnecting)
+			if !sleepContext(ctx, retry.next()) {
+				return
 			}
 		}
 	}()
+	return stopped
+}
+
+// sleepContext waits for the given duration or until the context is cancelled.
+//
+// Returns:
+// - false if the context was cancelled before the duration elapsed.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
 }
@@ -0,0 +1,95 @@
+package mbta
+
+import (
+	"context"
+	"explorer/internal/adapters/distribute"
+	"explorer/internal/adapters/mbta/fake"
+	"explorer/internal/adapters/store"
+	"explorer/internal/core/domain/models"
+	ports "explorer/internal/ports/streaming"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMBTAStreamSourceAgainstFakeServer(t *testing.T) {
+	server, err := fake.NewServer(20 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	streamStore := store.NewStreamStore()
+	distributor := distribute.NewClientDistributor(distribute.DefaultDistributorOptions())
+	client := make(chan models.StreamEvent, 100)
+	distributor.AddClient(client, ports.ClientOptions{})
+	source := NewMBTAStreamSource(distributor, streamStore, DefaultSourceOptions())
+
+	// The fake server does not need an API key
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	source.Start(ctx, httpServer.URL+"/vehicles?filter[route]=Red", "")
+
+	// The stream starts with every Red Line vehicle, then moves them one at a time
+	events := receiveEvents(t, client, 2)
+	if events[0].EventName() != models.ResetEvent || events[1].EventName() != models.UpdatedEvent {
+		t.Errorf("got events %q and %q, want a reset then an update", events[0].EventName(), events[1].EventName())
+	}
+	if state := source.State(); state != ports.StateConnected {
+		t.Errorf("state = %q, want %q", state, ports.StateConnected)
+	}
+
+	vehicles, loaded := streamStore.Vehicles()
+	if !loaded || len(vehicles) != 3 {
+		t.Fatalf("store holds %d vehicles, loaded %v, want the 3 Red Line vehicles", len(vehicles), loaded)
+	}
+	for _, vehicle := range vehicles {
+		if vehicle.Route != "Red" {
+			t.Errorf("vehicle %s route = %q, want Red", vehicle.ID, vehicle.Route)
+		}
+	}
+
+	// Cancelling the context stops the source
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for source.State() != ports.StateStopped {
+		if time.Now().After(deadline) {
+			t.Fatalf("state = %q after cancellation, want %q", source.State(), ports.StateStopped)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMBTAStreamSourceStopsOnAuthFailure(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+
+			source := NewMBTAStreamSource(distribute.NewClientDistributor(distribute.DefaultDistributorOptions()),
+				store.NewStreamStore(), SourceOptions{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// The source gives up without being cancelled, since a retry cannot succeed
+			select {
+			case <-source.Start(ctx, server.URL+"/vehicles", "bad-key"):
+			case <-time.After(5 * time.Second):
+				t.Fatal("source kept retrying after an authentication failure")
+			}
+			if state := source.State(); state != ports.StateFailed {
+				t.Errorf("state = %q, want %q", state, ports.StateFailed)
+			}
+			if n := requests.Load(); n != 1 {
+				t.Errorf("made %d requests, want 1", n)
+			}
+		})
+	}
+}
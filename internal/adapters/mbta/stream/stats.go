@@ -0,0 +1,86 @@
+package mbta
+
+import (
+	"explorer/internal/core/domain/models"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sourceStats collects counters about the upstream connection for status reporting.
+type sourceStats struct {
+	bytesReceived atomic.Uint64
+	reconnects    atomic.Uint64
+
+	mutex          sync.Mutex // Guards the fields below
+	connectedSince time.Time
+	lastError      string
+	lastErrorAt    time.Time
+	lastEventAt    time.Time
+	eventsByType   map[string]uint64
+}
+
+// recordError remembers the most recent upstream error.
+func (s *sourceStats) recordError(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastError = err.Error()
+	s.lastErrorAt = time.Now()
+}
+
+// recordEvent counts an event received from the upstream stream.
+func (s *sourceStats) recordEvent(eventType string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.eventsByType == nil {
+		s.eventsByType = make(map[string]uint64)
+	}
+	s.eventsByType[eventType]++
+	s.lastEventAt = time.Now()
+}
+
+// recordConnected marks the start of a connection, or its end when connected is false.
+func (s *sourceStats) recordConnected(connected bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if connected {
+		s.connectedSince = time.Now()
+	} else {
+		s.connectedSince = time.Time{}
+	}
+}
+
+// SourceStats returns a snapshot of the counters collected for the upstream connection.
+func (m *MBTAStreamSource) SourceStats() models.StreamSourceStats {
+	// Read the state before taking the stats lock: setState holds the state lock while recording the
+	// connection in the stats, so taking the two locks in the other order here could deadlock
+	state := m.State()
+
+	s := &m.stats
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	eventsByType := make(map[string]uint64, len(s.eventsByType))
+	for eventType, count := range s.eventsByType {
+		eventsByType[eventType] = count
+	}
+
+	return models.StreamSourceStats{
+		State:          string(state),
+		ConnectedSince: timeOrNil(s.connectedSince),
+		Reconnects:     s.reconnects.Load(),
+		LastError:      s.lastError,
+		LastErrorAt:    timeOrNil(s.lastErrorAt),
+		LastEventAt:    timeOrNil(s.lastEventAt),
+		BytesReceived:  s.bytesReceived.Load(),
+		EventsByType:   eventsByType,
+	}
+}
+
+// timeOrNil returns nil for the zero time, so it is omitted from JSON responses.
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
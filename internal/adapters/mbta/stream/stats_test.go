@@ -0,0 +1,51 @@
+package mbta
+
+import (
+	"explorer/internal/adapters/store"
+	ports "explorer/internal/ports/streaming"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSourceStatsDoesNotDeadlockWithStateChanges(t *testing.T) {
+	// Run the goroutines in parallel even on a single CPU, so the two lock orders can interleave
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	source := NewMBTAStreamSource(nil, store.NewStreamStore(), DefaultSourceOptions())
+
+	// Reading the stats while the state changes takes the stats and state locks concurrently
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 10000 {
+			if i%2 == 0 {
+				source.setState(ports.StateConnected)
+			} else {
+				source.setState(ports.StateReconnecting)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 10000 {
+			source.SourceStats()
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("SourceStats and setState deadlocked")
+	}
+
+	if reconnects := source.SourceStats().Reconnects; reconnects != 5000 {
+		t.Errorf("reconnects = %d, want 5000", reconnects)
+	}
+}
@@ -0,0 +1,66 @@
+package mbta
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// watchedReader wraps the stream body and records when bytes were last received,
+// including keep-alive comments, so an idle connection can be detected.
+type watchedReader struct {
+	io.ReadCloser
+	lastRead atomic.Int64   // Unix nanoseconds of the last successful read
+	received atomic.Bool    // Whether any bytes were received at all
+	bytes    *atomic.Uint64 // Running total of bytes received, shared across connections
+}
+
+// newWatchedReader wraps body, treating the connection as active from now and adding
+// every byte read to the bytes counter.
+func newWatchedReader(body io.ReadCloser, bytes *atomic.Uint64) *watchedReader {
+	r := &watchedReader{ReadCloser: body, bytes: bytes}
+	r.lastRead.Store(time.Now().UnixNano())
+	return r
+}
+
+func (r *watchedReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.lastRead.Store(time.Now().UnixNano())
+		r.received.Store(true)
+		r.bytes.Add(uint64(n))
+	}
+	return n, err
+}
+
+// idleFor returns how long ago bytes were last received.
+func (r *watchedReader) idleFor() time.Duration {
+	return time.Since(time.Unix(0, r.lastRead.Load()))
+}
+
+// watch closes the reader if nothing is received for longer than timeout, which ends the
+// scan and lets the stream reconnect. It returns when done is closed.
+//
+// Returns:
+// - true if the reader was closed because the connection was idle.
+func (r *watchedReader) watch(timeout time.Duration, done <-chan struct{}) bool {
+	if timeout <= 0 { // The watchdog is disabled
+		<-done
+		return false
+	}
+
+	ticker := time.NewTicker(timeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return false
+		case <-ticker.C:
+			if r.idleFor() > timeout {
+				r.Close()
+				return true
+			}
+		}
+	}
+}
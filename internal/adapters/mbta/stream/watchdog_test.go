@@ -0,0 +1,64 @@
+package mbta
+
+import (
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchedReaderClosesIdleConnection(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		keepOpen time.Duration // How long the stream keeps sending keep-alives before going quiet
+		idle     bool
+	}{
+		{name: "quiet connection", timeout: 20 * time.Millisecond, idle: true},
+		{name: "keep-alives then quiet", timeout: 20 * time.Millisecond, keepOpen: 60 * time.Millisecond, idle: true},
+		{name: "watchdog disabled", timeout: 0, idle: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeReader, pipeWriter := io.Pipe()
+			var bytes atomic.Uint64
+			body := newWatchedReader(pipeReader, &bytes)
+
+			// Read the stream until it is closed, like scanStream
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_, _ = io.Copy(io.Discard, body)
+			}()
+			go func() {
+				for deadline := time.Now().Add(tt.keepOpen); time.Now().Before(deadline); {
+					if _, err := pipeWriter.Write([]byte(":\n")); err != nil {
+						return
+					}
+					time.Sleep(tt.timeout / 4)
+				}
+			}()
+
+			idle := make(chan bool, 1)
+			start := time.Now()
+			go func() { idle <- body.watch(tt.timeout, done) }()
+
+			if !tt.idle {
+				// Without a watchdog the reader stays open until the stream ends
+				time.Sleep(50 * time.Millisecond)
+				pipeWriter.Close()
+			}
+			if got := <-idle; got != tt.idle {
+				t.Fatalf("watch reported idle %v, want %v", got, tt.idle)
+			}
+			if tt.idle && time.Since(start) < tt.keepOpen {
+				t.Errorf("closed after %s while keep-alives arrived for %s", time.Since(start), tt.keepOpen)
+			}
+			if tt.keepOpen > 0 && (!body.received.Load() || bytes.Load() == 0) {
+				t.Errorf("keep-alives not counted: received %v, %d bytes", body.received.Load(), bytes.Load())
+			}
+			<-done
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package store
+
+import (
+	"explorer/internal/core/domain/models"
+	"sort"
+	"sync"
+)
+
+// StreamStore is an in-memory, concurrency-safe set of the resources carried by a stream,
+// keyed by ID. It is kept up to date by applying the reset, add, update and remove events
+// received from the MBTA stream. A stream carries a single kind of resource, so a reset
+// of any kind replaces everything held.
+type StreamStore struct {
+	mutex       sync.RWMutex
+	resource    string // Kind of resource last applied ("vehicle", "prediction" or "alert"), empty if none
+	loaded      bool   // Whether a reset has been applied, so an empty store means there are no resources
+	vehicles    map[string]models.Vehicle
+	predictions map[string]models.Prediction
+	alerts      map[string]models.Alert
+}
+
+// Kinds of resources held by a StreamStore
+const (
+	resourceVehicle    = "vehicle"
+	resourcePrediction = "prediction"
+	resourceAlert      = "alert"
+)
+
+// NewStreamStore initializes an empty StreamStore.
+func NewStreamStore() *StreamStore {
+	return &StreamStore{
+		vehicles:    make(map[string]models.Vehicle),
+		predictions: make(map[string]models.Prediction),
+		alerts:      make(map[string]models.Alert),
+	}
+}
+
+// Apply updates the store with a stream event.
+func (s *StreamStore) Apply(event models.StreamEvent) {
+	s.mutex.Lock()         // Lock to ensure safe access to the resource maps
+	defer s.mutex.Unlock() // Unlock once the operation is done
+
+	switch e := event.(type) {
+	case models.VehicleReset:
+		s.clear()
+		s.resource = resourceVehicle
+		s.loaded = true
+		for _, vehicle := range e.Vehicles {
+			s.vehicles[vehicle.ID] = vehicle
+		}
+	case models.VehicleAdded:
+		s.resource = resourceVehicle
+		s.vehicles[e.Vehicle.ID] = e.Vehicle
+	case models.VehicleUpdated:
+		s.resource = resourceVehicle
+		s.vehicles[e.Vehicle.ID] = e.Vehicle
+	case models.VehicleRemoved:
+		delete(s.vehicles, e.VehicleID)
+
+	case models.PredictionReset:
+		s.clear()
+		s.resource = resourcePrediction
+		s.loaded = true
+		for _, prediction := range e.Predictions {
+			s.predictions[prediction.ID] = prediction
+		}
+	case models.PredictionAdded:
+		s.resource = resourcePrediction
+		s.predictions[e.Prediction.ID] = e.Prediction
+	case models.PredictionUpdated:
+		s.resource = resourcePrediction
+		s.predictions[e.Prediction.ID] = e.Prediction
+	case models.PredictionRemoved:
+		delete(s.predictions, e.PredictionID)
+
+	case models.AlertReset:
+		s.clear()
+		s.resource = resourceAlert
+		s.loaded = true
+		for _, alert := range e.Alerts {
+			s.alerts[alert.ID] = alert
+		}
+	case models.AlertAdded:
+		s.resource = resourceAlert
+		s.alerts[e.Alert.ID] = e.Alert
+	case models.AlertUpdated:
+		s.resource = resourceAlert
+		s.alerts[e.Alert.ID] = e.Alert
+	case models.AlertRemoved:
+		delete(s.alerts, e.AlertID)
+	}
+}
+
+// Snapshot returns a reset event carrying every resource currently held, ordered by ID, or nil
+// until an upstream reset has been applied. Once it has, the reset is returned even when empty,
+// so clients can tell that there are no resources from the resources not being loaded yet.
+func (s *StreamStore) Snapshot() models.StreamEvent {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if !s.loaded {
+		return nil
+	}
+
+	switch s.resource {
+	case resourceVehicle:
+		return models.VehicleReset{Vehicles: s.sortedVehicles()}
+
+	case resourcePrediction:
+		predictions := make([]models.Prediction, 0, len(s.predictions))
+		for _, prediction := range s.predictions {
+			predictions = append(predictions, prediction)
+		}
+		sort.Slice(predictions, func(i, j int) bool {
+			return predictions[i].ID < predictions[j].ID
+		})
+		return models.PredictionReset{Predictions: predictions}
+
+	case resourceAlert:
+		alerts := make([]models.Alert, 0, len(s.alerts))
+		for _, alert := range s.alerts {
+			alerts = append(alerts, alert)
+		}
+		sort.Slice(alerts, func(i, j int) bool {
+			return alerts[i].ID < alerts[j].ID
+		})
+		return models.AlertReset{Alerts: alerts}
+	}
+
+	return nil
+}
+
+// Vehicle returns the vehicle with the given ID, and false if the store does not hold it.
+func (s *StreamStore) Vehicle(id string) (models.Vehicle, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	vehicle, ok := s.vehicles[id]
+	return vehicle, ok
+}
+
+// Vehicles returns every vehicle held, ordered by ID, and false until a vehicle reset has been
+// applied, since the vehicles held until then are only those that happened to change.
+func (s *StreamStore) Vehicles() ([]models.Vehicle, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if !s.loaded || s.resource != resourceVehicle {
+		return nil, false
+	}
+	return s.sortedVehicles(), true
+}
+
+// sortedVehicles returns a copy of the vehicles held, ordered by ID. The caller must hold the mutex.
+func (s *StreamStore) sortedVehicles() []models.Vehicle {
+	vehicles := make([]models.Vehicle, 0, len(s.vehicles))
+	for _, vehicle := range s.vehicles {
+		vehicles = append(vehicles, vehicle)
+	}
+	// Sort by ID so clients get a stable ordering
+	sort.Slice(vehicles, func(i, j int) bool {
+		return vehicles[i].ID < vehicles[j].ID
+	})
+	return vehicles
+}
+
+// Clear removes every resource from the store, which is not loaded again until the next reset.
+func (s *StreamStore) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.clear()
+}
+
+// clear empties the resource maps. The caller must hold the mutex.
+func (s *StreamStore) clear() {
+	s.resource = ""
+	s.loaded = false
+	s.vehicles = make(map[string]models.Vehicle)
+	s.predictions = make(map[string]models.Prediction)
+	s.alerts = make(map[string]models.Alert)
+}
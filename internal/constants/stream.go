@@ -1,3 +1,68 @@
 package constants
 
-var MbtaVehicleLiveStreamUrl = "https://api-v3.mbta.com/vehicles?filter[route]=Red,Orange,Blue,Green-B,Green-C,Green-D,Green-E,Mattapan"
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MbtaApiBaseUrl is the default base URL of the MBTA V3 API
+const MbtaApiBaseUrl = "https://api-v3.mbta.com"
+
+// SubwayRouteIDs lists the routes served by the shared subway vehicle stream
+var SubwayRouteIDs = []string{"Red", "Orange", "Blue", "Green-B", "Green-C", "Green-D", "Green-E", "Mattapan"}
+
+// VehicleStreamUrl returns the URL of the vehicle stream for the given routes on the API at baseURL
+func VehicleStreamUrl(baseURL string, routeIDs []string) string {
+	query := url.Values{}
+	query.Set("filter[route]", strings.Join(routeIDs, ","))
+	return baseURL + "/vehicles?" + query.Encode()
+}
+
+// PredictionStreamUrl returns the URL of the prediction stream for the given stops and/or routes on the API at baseURL
+func PredictionStreamUrl(baseURL string, stopIDs, routeIDs []string) string {
+	query := url.Values{}
+	if len(stopIDs) > 0 {
+		query.Set("filter[stop]", strings.Join(stopIDs, ","))
+	}
+	if len(routeIDs) > 0 {
+		query.Set("filter[route]", strings.Join(routeIDs, ","))
+	}
+	return baseURL + "/predictions?" + query.Encode()
+}
+
+// AlertStreamUrl returns the URL of the stream of alerts active now for the given routes, stops, activities and
+// severities on the API at baseURL. Empty lists do not filter.
+func AlertStreamUrl(baseURL string, routeIDs, stopIDs, activities []string, severities []int) string {
+	query := url.Values{}
+	query.Set("filter[datetime]", "NOW")
+	if len(routeIDs) > 0 {
+		query.Set("filter[route]", strings.Join(routeIDs, ","))
+	}
+	if len(stopIDs) > 0 {
+		query.Set("filter[stop]", strings.Join(stopIDs, ","))
+	}
+	if len(activities) > 0 {
+		query.Set("filter[activity]", strings.Join(activities, ","))
+	}
+	if len(severities) > 0 {
+		values := make([]string, len(severities))
+		for i, severity := range severities {
+			values[i] = strconv.Itoa(severity)
+		}
+		query.Set("filter[severity]", strings.Join(values, ","))
+	}
+	return baseURL + "/alerts?" + query.Encode()
+}
+
+// VehicleStreamByRouteTypeUrl returns the URL of the vehicle stream for every route of the given
+// route types on the API at baseURL
+func VehicleStreamByRouteTypeUrl(baseURL string, routeTypes []int) string {
+	types := make([]string, len(routeTypes))
+	for i, routeType := range routeTypes {
+		types[i] = strconv.Itoa(routeType)
+	}
+	query := url.Values{}
+	query.Set("filter[route_type]", strings.Join(types, ","))
+	return baseURL + "/vehicles?" + query.Encode()
+}
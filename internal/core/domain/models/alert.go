@@ -0,0 +1,135 @@
+package models
+
+import "time"
+
+// Alert is a service alert, e.g. a shuttle, a delay or an elevator outage
+type Alert struct {
+	ID               string           `json:"id"`
+	Header           string           `json:"header"`       // Summary of the alert
+	ShortHeader      string           `json:"short_header"` // Shorter summary for small screens
+	Description      *string          `json:"description"`  // Details, nil when the header says it all
+	Effect           string           `json:"effect"`       // E.g. "SHUTTLE", "DELAY" or "ELEVATOR_CLOSURE"
+	Cause            string           `json:"cause"`        // E.g. "MAINTENANCE", "UNKNOWN_CAUSE"
+	Severity         int              `json:"severity"`     // 0 (least severe) to 10 (most severe)
+	Lifecycle        string           `json:"lifecycle"`    // E.g. "NEW", "ONGOING" or "UPCOMING"
+	Timeframe        *string          `json:"timeframe"`    // Text describing when the alert applies, e.g. "through Friday"
+	URL              *string          `json:"url"`
+	ActivePeriods    []AlertPeriod    `json:"active_periods"`
+	InformedEntities []InformedEntity `json:"informed_entities"` // What the alert applies to, as listed by the MBTA
+	Routes           []AffectedRoute  `json:"routes"`            // Routes named by the informed entities, resolved from the route catalog
+	Stops            []AffectedStop   `json:"stops"`             // Stops named by the informed entities, with their names
+	Modes            []string         `json:"modes"`             // Modes affected as a whole, e.g. "bus" for an alert on every bus
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+}
+
+// AlertPeriod is a time span during which an alert is in effect
+type AlertPeriod struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end"` // Nil until the end is known
+}
+
+// InformedEntity is a facility, stop, trip, route or route type an alert applies to, for the given activities.
+// Only the fields that narrow the alert are set.
+type InformedEntity struct {
+	Activities  []string `json:"activities"` // E.g. "BOARD", "EXIT", "RIDE" or "USING_WHEELCHAIR"
+	RouteID     string   `json:"route_id,omitempty"`
+	RouteType   *int     `json:"route_type,omitempty"`
+	StopID      string   `json:"stop_id,omitempty"`
+	TripID      string   `json:"trip_id,omitempty"`
+	DirectionID *int     `json:"direction_id,omitempty"`
+	FacilityID  string   `json:"facility_id,omitempty"` // E.g. an elevator or escalator
+}
+
+// AffectedRoute is a route affected by an alert
+type AffectedRoute struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"` // Empty if the route is not in the route catalog
+	Type  int    `json:"type"`
+	Mode  string `json:"mode"`
+	Color string `json:"color"`
+}
+
+// AffectedStop is a stop affected by an alert
+type AffectedStop struct {
+	ID   string `json:"id"`
+	Name string `json:"name"` // Empty if the stop could not be looked up
+}
+
+// AlertFilter selects the alerts to fetch or stream. Empty fields do not filter.
+type AlertFilter struct {
+	RouteIDs   []string // Alerts affecting any of these routes
+	StopIDs    []string // Alerts affecting any of these stops
+	Activities []string // Alerts affecting any of these activities, the MBTA defaults to BOARD, EXIT and RIDE
+	Severities []int    // Alerts of any of these severities
+}
+
+// AlertResource is an alert as returned by the MBTA API
+type AlertResource struct {
+	ID         string          `json:"id"`
+	Attributes AlertAttributes `json:"attributes"`
+}
+
+type AlertAttributes struct {
+	ActivePeriod   []AlertPeriod          `json:"active_period"`
+	Cause          string                 `json:"cause"`
+	CreatedAt      time.Time              `json:"created_at"`
+	Description    *string                `json:"description"`
+	Effect         string                 `json:"effect"`
+	Header         string                 `json:"header"`
+	InformedEntity []InformedEntityObject `json:"informed_entity"`
+	Lifecycle      string                 `json:"lifecycle"`
+	Severity       int                    `json:"severity"`
+	ShortHeader    string                 `json:"short_header"`
+	Timeframe      *string                `json:"timeframe"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+	URL            *string                `json:"url"`
+}
+
+// InformedEntityObject is an informed entity as returned by the MBTA API
+type InformedEntityObject struct {
+	Activities  []string `json:"activities"`
+	DirectionID *int     `json:"direction_id"`
+	Facility    string   `json:"facility"`
+	Route       string   `json:"route"`
+	RouteType   *int     `json:"route_type"`
+	Stop        string   `json:"stop"`
+	Trip        string   `json:"trip"`
+}
+
+// Alert flattens the resource. The affected routes, stops and modes are left for an AlertResolver to fill in.
+func (r AlertResource) Alert() Alert {
+	entities := make([]InformedEntity, len(r.Attributes.InformedEntity))
+	for i, entity := range r.Attributes.InformedEntity {
+		entities[i] = InformedEntity{
+			Activities:  entity.Activities,
+			RouteID:     entity.Route,
+			RouteType:   entity.RouteType,
+			StopID:      entity.Stop,
+			TripID:      entity.Trip,
+			DirectionID: entity.DirectionID,
+			FacilityID:  entity.Facility,
+		}
+	}
+
+	return Alert{
+		ID:               r.ID,
+		Header:           r.Attributes.Header,
+		ShortHeader:      r.Attributes.ShortHeader,
+		Description:      r.Attributes.Description,
+		Effect:           r.Attributes.Effect,
+		Cause:            r.Attributes.Cause,
+		Severity:         r.Attributes.Severity,
+		Lifecycle:        r.Attributes.Lifecycle,
+		Timeframe:        r.Attributes.Timeframe,
+		URL:              r.Attributes.URL,
+		ActivePeriods:    r.Attributes.ActivePeriod,
+		InformedEntities: entities,
+		CreatedAt:        r.Attributes.CreatedAt,
+		UpdatedAt:        r.Attributes.UpdatedAt,
+	}
+}
+
+type AlertsResponse struct {
+	Data []AlertResource `json:"data"`
+}
@@ -0,0 +1,40 @@
+package models
+
+// AlertReset replaces the entire set of alerts
+type AlertReset struct {
+	ID     uint64
+	Alerts []Alert
+}
+
+// AlertAdded reports an alert that was not previously known
+type AlertAdded struct {
+	ID    uint64
+	Alert Alert
+}
+
+// AlertUpdated reports the new state of a known alert
+type AlertUpdated struct {
+	ID    uint64
+	Alert Alert
+}
+
+// AlertRemoved reports that an alert is no longer in effect
+type AlertRemoved struct {
+	ID      uint64
+	AlertID string
+}
+
+func (e AlertReset) EventID() uint64   { return e.ID }
+func (e AlertAdded) EventID() uint64   { return e.ID }
+func (e AlertUpdated) EventID() uint64 { return e.ID }
+func (e AlertRemoved) EventID() uint64 { return e.ID }
+
+func (e AlertReset) EventName() string   { return ResetEvent }
+func (e AlertAdded) EventName() string   { return AddedEvent }
+func (e AlertUpdated) EventName() string { return UpdatedEvent }
+func (e AlertRemoved) EventName() string { return RemovedEvent }
+
+func (e AlertReset) WithID(id uint64) StreamEvent   { e.ID = id; return e }
+func (e AlertAdded) WithID(id uint64) StreamEvent   { e.ID = id; return e }
+func (e AlertUpdated) WithID(id uint64) StreamEvent { e.ID = id; return e }
+func (e AlertRemoved) WithID(id uint64) StreamEvent { e.ID = id; return e }
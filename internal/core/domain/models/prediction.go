@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// Prediction is the predicted arrival and departure of a trip at a stop
+type Prediction struct {
+	ID                   string     `json:"id"`
+	RouteID              string     `json:"route_id"`
+	StopID               string     `json:"stop_id"`
+	TripID               string     `json:"trip_id"`
+	VehicleID            string     `json:"vehicle_id,omitempty"` // Empty until a vehicle is assigned to the trip
+	DirectionID          int        `json:"direction_id"`
+	StopSequence         int        `json:"stop_sequence"`
+	ArrivalTime          *time.Time `json:"arrival_time"`   // Nil at the first stop of a trip
+	DepartureTime        *time.Time `json:"departure_time"` // Nil at the last stop of a trip
+	ArrivalUncertainty   *int       `json:"arrival_uncertainty"`
+	DepartureUncertainty *int       `json:"departure_uncertainty"`
+	Status               *string    `json:"status"`                // Text to show instead of a time, e.g. "Boarding"
+	ScheduleRelationship *string    `json:"schedule_relationship"` // E.g. "ADDED", "CANCELLED" or "SKIPPED", nil when scheduled
+	LastTrip             bool       `json:"last_trip"`
+	Revenue              string     `json:"revenue"`
+}
+
+// Time returns the predicted arrival time, or the departure time at the first stop of a trip.
+func (p Prediction) Time() *time.Time {
+	if p.ArrivalTime != nil {
+		return p.ArrivalTime
+	}
+	return p.DepartureTime
+}
+
+// PredictionResource is a prediction as returned by the MBTA API
+type PredictionResource struct {
+	ID            string                  `json:"id"`
+	Attributes    PredictionAttributes    `json:"attributes"`
+	Relationships PredictionRelationships `json:"relationships"`
+}
+
+type PredictionAttributes struct {
+	ArrivalTime          *time.Time `json:"arrival_time"`
+	ArrivalUncertainty   *int       `json:"arrival_uncertainty"`
+	DepartureTime        *time.Time `json:"departure_time"`
+	DepartureUncertainty *int       `json:"departure_uncertainty"`
+	DirectionID          int        `json:"direction_id"`
+	LastTrip             bool       `json:"last_trip"`
+	Revenue              string     `json:"revenue"`
+	ScheduleRelationship *string    `json:"schedule_relationship"`
+	Status               *string    `json:"status"`
+	StopSequence         int        `json:"stop_sequence"`
+}
+
+type PredictionRelationships struct {
+	Route   RouteRelation `json:"route"`
+	Stop    RouteRelation `json:"stop"`
+	Trip    RouteRelation `json:"trip"`
+	Vehicle RouteRelation `json:"vehicle"`
+}
+
+// Prediction flattens the resource, replacing its relationships with the IDs they point to
+func (r PredictionResource) Prediction() Prediction {
+	return Prediction{
+		ID:                   r.ID,
+		RouteID:              r.Relationships.Route.Data.ID,
+		StopID:               r.Relationships.Stop.Data.ID,
+		TripID:               r.Relationships.Trip.Data.ID,
+		VehicleID:            r.Relationships.Vehicle.Data.ID,
+		DirectionID:          r.Attributes.DirectionID,
+		StopSequence:         r.Attributes.StopSequence,
+		ArrivalTime:          r.Attributes.ArrivalTime,
+		DepartureTime:        r.Attributes.DepartureTime,
+		ArrivalUncertainty:   r.Attributes.ArrivalUncertainty,
+		DepartureUncertainty: r.Attributes.DepartureUncertainty,
+		Status:               r.Attributes.Status,
+		ScheduleRelationship: r.Attributes.ScheduleRelationship,
+		LastTrip:             r.Attributes.LastTrip,
+		Revenue:              r.Attributes.Revenue,
+	}
+}
+
+type PredictionsResponse struct {
+	Data []PredictionResource `json:"data"`
+}
@@ -0,0 +1,40 @@
+package models
+
+// PredictionReset replaces the entire set of predictions
+type PredictionReset struct {
+	ID          uint64
+	Predictions []Prediction
+}
+
+// PredictionAdded reports a prediction that was not previously known
+type PredictionAdded struct {
+	ID         uint64
+	Prediction Prediction
+}
+
+// PredictionUpdated reports the new state of a known prediction
+type PredictionUpdated struct {
+	ID         uint64
+	Prediction Prediction
+}
+
+// PredictionRemoved reports that a prediction no longer applies, e.g. because the trip left the stop
+type PredictionRemoved struct {
+	ID           uint64
+	PredictionID string
+}
+
+func (e PredictionReset) EventID() uint64   { return e.ID }
+func (e PredictionAdded) EventID() uint64   { return e.ID }
+func (e PredictionUpdated) EventID() uint64 { return e.ID }
+func (e PredictionRemoved) EventID() uint64 { return e.ID }
+
+func (e PredictionReset) EventName() string   { return ResetEvent }
+func (e PredictionAdded) EventName() string   { return AddedEvent }
+func (e PredictionUpdated) EventName() string { return UpdatedEvent }
+func (e PredictionRemoved) EventName() string { return RemovedEvent }
+
+func (e PredictionReset) WithID(id uint64) StreamEvent   { e.ID = id; return e }
+func (e PredictionAdded) WithID(id uint64) StreamEvent   { e.ID = id; return e }
+func (e PredictionUpdated) WithID(id uint64) StreamEvent { e.ID = id; return e }
+func (e PredictionRemoved) WithID(id uint64) StreamEvent { e.ID = id; return e }
@@ -1,6 +1,70 @@
 package models
 
+// Route describes an MBTA route for the route catalog
 type Route struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID                    string   `json:"id"`
+	Name                  string   `json:"name"` // Long name, or the short name for routes without one (e.g. buses)
+	LongName              string   `json:"long_name"`
+	ShortName             string   `json:"short_name"`
+	Description           string   `json:"description"`
+	Color                 string   `json:"color"`      // Hex color without the leading "#", e.g. "DA291C"
+	TextColor             string   `json:"text_color"` // Hex color of text drawn over Color
+	Type                  int      `json:"type"`       // GTFS route type, see RouteTypeMode
+	Mode                  string   `json:"mode"`       // Name of the route type, e.g. "subway" or "bus"
+	SortOrder             int      `json:"sort_order"`
+	DirectionNames        []string `json:"direction_names"`        // Indexed by direction ID, e.g. ["South", "North"]
+	DirectionDestinations []string `json:"direction_destinations"` // Indexed by direction ID, e.g. ["Ashmont/Braintree", "Alewife"]
+	Line                  *Line    `json:"line"`                   // Line the route belongs to, if any
+}
+
+// Line groups routes that are presented together, e.g. the Green Line branches
+type Line struct {
+	ID        string `json:"id"`
+	LongName  string `json:"long_name"`
+	ShortName string `json:"short_name"`
+	Color     string `json:"color"`
+	TextColor string `json:"text_color"`
+}
+
+// RouteResource is a route as returned by the MBTA API
+type RouteResource struct {
+	ID            string             `json:"id"`
+	Attributes    RouteAttributes    `json:"attributes"`
+	Relationships RouteRelationships `json:"relationships"`
+}
+
+type RouteAttributes struct {
+	Color                 string   `json:"color"`
+	Description           string   `json:"description"`
+	DirectionDestinations []string `json:"direction_destinations"`
+	DirectionNames        []string `json:"direction_names"`
+	LongName              string   `json:"long_name"`
+	ShortName             string   `json:"short_name"`
+	SortOrder             int      `json:"sort_order"`
+	TextColor             string   `json:"text_color"`
+	Type                  int      `json:"type"`
+}
+
+type RouteRelationships struct {
+	Line RouteRelation `json:"line"`
+}
+
+// LineResource is a line as returned by the MBTA API
+type LineResource struct {
+	ID         string         `json:"id"`
+	Attributes LineAttributes `json:"attributes"`
+}
+
+type LineAttributes struct {
+	Color     string `json:"color"`
+	LongName  string `json:"long_name"`
+	ShortName string `json:"short_name"`
+	TextColor string `json:"text_color"`
+}
+
+// RoutesResponse is the response of the MBTA /routes endpoint, with the lines of the routes
+// included when requested with include=line
+type RoutesResponse struct {
+	Data     []RouteResource `json:"data"`
+	Included []LineResource  `json:"included"`
 }
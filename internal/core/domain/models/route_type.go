@@ -0,0 +1,30 @@
+package models
+
+// Route types defined by GTFS and used by the MBTA API to identify the mode of a route
+const (
+	RouteTypeLightRail    = 0 // Green Line and Mattapan Trolley
+	RouteTypeSubway       = 1 // Red, Orange and Blue Lines
+	RouteTypeCommuterRail = 2
+	RouteTypeBus          = 3
+	RouteTypeFerry        = 4
+)
+
+// routeTypeModes names the mode of each route type
+var routeTypeModes = map[int]string{
+	RouteTypeLightRail:    "light_rail",
+	RouteTypeSubway:       "subway",
+	RouteTypeCommuterRail: "commuter_rail",
+	RouteTypeBus:          "bus",
+	RouteTypeFerry:        "ferry",
+}
+
+// IsValidRouteType reports whether routeType is one of the route types used by the MBTA.
+func IsValidRouteType(routeType int) bool {
+	_, ok := routeTypeModes[routeType]
+	return ok
+}
+
+// RouteTypeMode returns the name of the mode of a route type (e.g., "bus"), or an empty string if it is unknown.
+func RouteTypeMode(routeType int) string {
+	return routeTypeModes[routeType]
+}
@@ -0,0 +1,91 @@
+package models
+
+import "time"
+
+// Schedule is the scheduled arrival and departure of a trip at a stop
+type Schedule struct {
+	ID            string     `json:"id"`
+	RouteID       string     `json:"route_id"`
+	StopID        string     `json:"stop_id"`
+	TripID        string     `json:"trip_id"`
+	Headsign      string     `json:"headsign"` // Destination shown on the vehicle at this stop
+	DirectionID   int        `json:"direction_id"`
+	StopSequence  int        `json:"stop_sequence"`
+	ArrivalTime   *time.Time `json:"arrival_time"`   // Nil at the first stop of a trip
+	DepartureTime *time.Time `json:"departure_time"` // Nil at the last stop of a trip
+	PickupType    int        `json:"pickup_type"`    // 0 regular, 1 none, 2 phone the agency, 3 coordinate with the driver
+	DropOffType   int        `json:"drop_off_type"`  // Same values as PickupType
+	Timepoint     bool       `json:"timepoint"`      // Whether the times are exact rather than approximate
+}
+
+// Time returns the scheduled arrival time, or the departure time at the first stop of a trip.
+func (s Schedule) Time() *time.Time {
+	if s.ArrivalTime != nil {
+		return s.ArrivalTime
+	}
+	return s.DepartureTime
+}
+
+// ScheduleFilter selects the schedules to fetch. At least one of RouteID, StopID and TripID must be set.
+type ScheduleFilter struct {
+	RouteID     string // Comma separated route IDs
+	StopID      string // Comma separated stop IDs
+	TripID      string // Comma separated trip IDs
+	Date        string // Service date as YYYY-MM-DD, today's service date if empty unless TripID is set
+	DirectionID *int   // Nil for both directions
+}
+
+// ScheduleResource is a schedule as returned by the MBTA API
+type ScheduleResource struct {
+	ID            string                `json:"id"`
+	Attributes    ScheduleAttributes    `json:"attributes"`
+	Relationships ScheduleRelationships `json:"relationships"`
+}
+
+type ScheduleAttributes struct {
+	ArrivalTime   *time.Time `json:"arrival_time"`
+	DepartureTime *time.Time `json:"departure_time"`
+	DirectionID   int        `json:"direction_id"`
+	DropOffType   int        `json:"drop_off_type"`
+	PickupType    int        `json:"pickup_type"`
+	StopHeadsign  *string    `json:"stop_headsign"` // Overrides the trip headsign at this stop, if set
+	StopSequence  int        `json:"stop_sequence"`
+	Timepoint     bool       `json:"timepoint"`
+}
+
+type ScheduleRelationships struct {
+	Route RouteRelation `json:"route"`
+	Stop  RouteRelation `json:"stop"`
+	Trip  RouteRelation `json:"trip"`
+}
+
+// Schedule flattens the resource, replacing its relationships with the IDs they point to. The headsign
+// is the stop headsign if set, and otherwise tripHeadsign.
+func (r ScheduleResource) Schedule(tripHeadsign string) Schedule {
+	headsign := tripHeadsign
+	if r.Attributes.StopHeadsign != nil && *r.Attributes.StopHeadsign != "" {
+		headsign = *r.Attributes.StopHeadsign
+	}
+
+	return Schedule{
+		ID:            r.ID,
+		RouteID:       r.Relationships.Route.Data.ID,
+		StopID:        r.Relationships.Stop.Data.ID,
+		TripID:        r.Relationships.Trip.Data.ID,
+		Headsign:      headsign,
+		DirectionID:   r.Attributes.DirectionID,
+		StopSequence:  r.Attributes.StopSequence,
+		ArrivalTime:   r.Attributes.ArrivalTime,
+		DepartureTime: r.Attributes.DepartureTime,
+		PickupType:    r.Attributes.PickupType,
+		DropOffType:   r.Attributes.DropOffType,
+		Timepoint:     r.Attributes.Timepoint,
+	}
+}
+
+// SchedulesResponse is the response of the MBTA /schedules endpoint, with the trips of the schedules
+// included when requested with include=trip
+type SchedulesResponse struct {
+	Data     []ScheduleResource `json:"data"`
+	Included []TripResource     `json:"included"`
+}
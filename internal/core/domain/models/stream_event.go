@@ -0,0 +1,18 @@
+package models
+
+// Names of the stream events, matching the MBTA V3 streaming API
+const (
+	ResetEvent   = "reset"
+	AddedEvent   = "add"
+	UpdatedEvent = "update"
+	RemovedEvent = "remove"
+)
+
+// StreamEvent is a change to the set of resources (e.g. vehicles, predictions or alerts) carried
+// through the streaming pipeline. It is one of the reset, added, updated or removed events
+// of a resource, and is only serialized by the transport delivering it to a client.
+type StreamEvent interface {
+	EventID() uint64              // Monotonic event ID, 0 if the event is not numbered
+	EventName() string            // Name of the event, e.g. "reset"
+	WithID(id uint64) StreamEvent // Copy of the event numbered with the given ID
+}
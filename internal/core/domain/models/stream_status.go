@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// StreamStatus reports the health of one upstream stream and its clients.
+type StreamStatus struct {
+	URL         string                 `json:"url"`
+	Running     bool                   `json:"running"` // Whether the upstream stream is started
+	Clients     int                    `json:"clients"` // Clients attached to the stream
+	Source      StreamSourceStats      `json:"source"`
+	Distributor StreamDistributorStats `json:"distributor"`
+}
+
+// StreamSourceStats holds counters collected while reading an upstream stream.
+type StreamSourceStats struct {
+	State          string            `json:"state"`
+	ConnectedSince *time.Time        `json:"connected_since,omitempty"`
+	Reconnects     uint64            `json:"reconnects"`
+	LastError      string            `json:"last_error,omitempty"`
+	LastErrorAt    *time.Time        `json:"last_error_at,omitempty"`
+	LastEventAt    *time.Time        `json:"last_event_at,omitempty"`
+	BytesReceived  uint64            `json:"bytes_received"`
+	EventsByType   map[string]uint64 `json:"events_by_type"`
+}
+
+// StreamDistributorStats holds counters collected while distributing events to clients.
+type StreamDistributorStats struct {
+	Clients         int      `json:"clients"`
+	Broadcasts      uint64   `json:"broadcasts"`
+	DroppedMessages uint64   `json:"dropped_messages"` // Total, including clients that have since disconnected
+	ClientDrops     []uint64 `json:"client_drops"`     // Dropped messages of each connected client
+}
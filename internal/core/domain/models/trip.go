@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// TripResource is a trip as returned by the MBTA API
+type TripResource struct {
+	ID            string            `json:"id"`
+	Attributes    TripAttributes    `json:"attributes"`
+	Relationships TripRelationships `json:"relationships"`
+}
+
+type TripAttributes struct {
+	BikesAllowed         int    `json:"bikes_allowed"`
+	BlockID              string `json:"block_id"`
+	DirectionID          int    `json:"direction_id"`
+	Headsign             string `json:"headsign"`
+	Name                 string `json:"name"` // Public trip number, e.g. commuter rail train numbers
+	Revenue              string `json:"revenue"`
+	WheelchairAccessible int    `json:"wheelchair_accessible"`
+}
+
+type TripRelationships struct {
+	Route   RouteRelation `json:"route"`
+	Service RouteRelation `json:"service"`
+	Shape   RouteRelation `json:"shape"`
+}
+
+// Trip is a single journey of a vehicle along a route
+type Trip struct {
+	ID                   string `json:"id"`
+	RouteID              string `json:"route_id"`
+	Headsign             string `json:"headsign"`
+	Name                 string `json:"name"` // Public trip number, e.g. commuter rail train numbers, often empty
+	DirectionID          int    `json:"direction_id"`
+	BlockID              string `json:"block_id"`   // Trips run in sequence by the same vehicle share a block
+	ServiceID            string `json:"service_id"` // Set of dates the trip runs on
+	ShapeID              string `json:"shape_id"`
+	WheelchairAccessible int    `json:"wheelchair_accessible"` // 0 unknown, 1 accessible, 2 inaccessible
+	BikesAllowed         int    `json:"bikes_allowed"`         // 0 unknown, 1 allowed, 2 not allowed
+}
+
+// Trip flattens the resource, replacing its relationships with the IDs they point to
+func (r TripResource) Trip() Trip {
+	return Trip{
+		ID:                   r.ID,
+		RouteID:              r.Relationships.Route.Data.ID,
+		Headsign:             r.Attributes.Headsign,
+		Name:                 r.Attributes.Name,
+		DirectionID:          r.Attributes.DirectionID,
+		BlockID:              r.Attributes.BlockID,
+		ServiceID:            r.Relationships.Service.Data.ID,
+		ShapeID:              r.Relationships.Shape.Data.ID,
+		WheelchairAccessible: r.Attributes.WheelchairAccessible,
+		BikesAllowed:         r.Attributes.BikesAllowed,
+	}
+}
+
+// TripResponse is the response of the MBTA /trips/{id} endpoint
+type TripResponse struct {
+	Data TripResource `json:"data"`
+}
+
+// TripDetail is a trip with its stops, the vehicle serving it and the shape it follows
+type TripDetail struct {
+	Trip
+	Stops   []TripStop  `json:"stops"`   // Stops in the order they are served
+	Vehicle *Vehicle    `json:"vehicle"` // Nil until a vehicle is assigned to the trip
+	Shape   [][]float64 `json:"shape"`   // Latitude/longitude pairs, nil if the shape is unavailable
+}
+
+// TripStop is a stop of a trip with its scheduled and predicted times
+type TripStop struct {
+	StopID               string     `json:"stop_id"`
+	StopName             string     `json:"stop_name"` // Empty if the stop could not be looked up
+	StopSequence         int        `json:"stop_sequence"`
+	ScheduledArrival     *time.Time `json:"scheduled_arrival"`
+	ScheduledDeparture   *time.Time `json:"scheduled_departure"`
+	PredictedArrival     *time.Time `json:"predicted_arrival"`   // Nil without a prediction, e.g. once the vehicle has left the stop
+	PredictedDeparture   *time.Time `json:"predicted_departure"` // Nil without a prediction
+	Status               *string    `json:"status"`              // Text to show instead of a time, e.g. "Boarding"
+	ScheduleRelationship *string    `json:"schedule_relationship"`
+}
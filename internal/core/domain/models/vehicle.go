@@ -3,16 +3,50 @@ package models
 type Vehicle struct {
 	ID            string            `json:"id"`
 	Route         string            `json:"route"`
+	RouteType     *int              `json:"route_type,omitempty"` // GTFS route type of the route, if known
+	Mode          string            `json:"mode,omitempty"`       // Name of the route type, e.g. "bus"
 	Attributes    VehicleAttributes `json:"attributes"`
 	Relationships *VehicleRelations `json:"relationships,omitempty"`
 }
 
+// RouteID returns the vehicle's route, falling back to its route relationship
+// when the Route field has not been populated.
+func (v Vehicle) RouteID() string {
+	if v.Route == "" && v.Relationships != nil {
+		return v.Relationships.Route.Data.ID
+	}
+	return v.Route
+}
+
+// TripID returns the ID of the trip the vehicle is serving, or an empty string if it has none.
+func (v Vehicle) TripID() string {
+	if v.Relationships == nil {
+		return ""
+	}
+	return v.Relationships.Trip.Data.ID
+}
+
+// StopID returns the ID of the stop the vehicle is at or heading to, or an empty string if unknown.
+func (v Vehicle) StopID() string {
+	if v.Relationships == nil {
+		return ""
+	}
+	return v.Relationships.Stop.Data.ID
+}
+
+// SetRouteType records the route type of the vehicle's route along with the name of its mode.
+func (v *Vehicle) SetRouteType(routeType int) {
+	v.RouteType = &routeType
+	v.Mode = RouteTypeMode(routeType)
+}
+
 type VehicleAttributes struct {
 	Bearing             int                `json:"bearing"`
 	Carriages           []VehicleCarriages `json:"carriages"`
 	CurrentStatus       string             `json:"current_status"`
 	CurrentStopSequence int                `json:"current_stop_sequence"`
 	Direction           int                `json:"direction"`
+	DirectionID         int                `json:"direction_id"`
 	Label               string             `json:"label"`
 	Latitude            float64            `json:"latitude"`
 	Longitude           float64            `json:"longitude"`
@@ -30,6 +64,8 @@ type VehicleCarriages struct {
 
 type VehicleRelations struct {
 	Route RouteRelation `json:"route"`
+	Trip  RouteRelation `json:"trip"` // Trip the vehicle is serving, empty when it is not in service
+	Stop  RouteRelation `json:"stop"` // Stop the vehicle is at or heading to
 }
 
 type RouteRelation struct {
@@ -0,0 +1,40 @@
+package models
+
+// VehicleReset replaces the entire set of vehicles
+type VehicleReset struct {
+	ID       uint64
+	Vehicles []Vehicle
+}
+
+// VehicleAdded reports a vehicle that was not previously known
+type VehicleAdded struct {
+	ID      uint64
+	Vehicle Vehicle
+}
+
+// VehicleUpdated reports the new state of a known vehicle
+type VehicleUpdated struct {
+	ID      uint64
+	Vehicle Vehicle
+}
+
+// VehicleRemoved reports that a vehicle is no longer in service
+type VehicleRemoved struct {
+	ID        uint64
+	VehicleID string
+}
+
+func (e VehicleReset) EventID() uint64   { return e.ID }
+func (e VehicleAdded) EventID() uint64   { return e.ID }
+func (e VehicleUpdated) EventID() uint64 { return e.ID }
+func (e VehicleRemoved) EventID() uint64 { return e.ID }
+
+func (e VehicleReset) EventName() string   { return ResetEvent }
+func (e VehicleAdded) EventName() string   { return AddedEvent }
+func (e VehicleUpdated) EventName() string { return UpdatedEvent }
+func (e VehicleRemoved) EventName() string { return RemovedEvent }
+
+func (e VehicleReset) WithID(id uint64) StreamEvent   { e.ID = id; return e }
+func (e VehicleAdded) WithID(id uint64) StreamEvent   { e.ID = id; return e }
+func (e VehicleUpdated) WithID(id uint64) StreamEvent { e.ID = id; return e }
+func (e VehicleRemoved) WithID(id uint64) StreamEvent { e.ID = id; return e }
@@ -0,0 +1,30 @@
+package models
+
+import "slices"
+
+// VehicleFilter restricts a set of vehicles to the given routes, route types and direction.
+// The zero value matches every vehicle.
+type VehicleFilter struct {
+	RouteIDs   []string // Route IDs to keep; nil for all routes, empty (non-nil) for none
+	RouteTypes []int    // Route types to keep (see RouteTypeMode); nil for all route types
+	Direction  *int     // Direction ID to keep, or nil for both directions
+}
+
+// IsEmpty reports whether the filter lets every vehicle through.
+func (f VehicleFilter) IsEmpty() bool {
+	return f.RouteIDs == nil && f.RouteTypes == nil && f.Direction == nil
+}
+
+// Matches reports whether the given vehicle satisfies the filter.
+func (f VehicleFilter) Matches(vehicle Vehicle) bool {
+	if f.Direction != nil && vehicle.Attributes.DirectionID != *f.Direction {
+		return false
+	}
+
+	// Vehicles whose route type is unknown cannot be shown to match a route type filter
+	if f.RouteTypes != nil && (vehicle.RouteType == nil || !slices.Contains(f.RouteTypes, *vehicle.RouteType)) {
+		return false
+	}
+
+	return f.RouteIDs == nil || slices.Contains(f.RouteIDs, vehicle.RouteID())
+}
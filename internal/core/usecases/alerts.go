@@ -0,0 +1,146 @@
+package usecases
+
+import (
+	"context"
+	"explorer/internal/core/domain/models"
+	"log"
+	"strings"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+const (
+	stopNameCachePrefix = "stop-name:" // Cache key prefix of stop names, followed by the stop ID
+	stopLookupBatchSize = 100          // Most stops looked up in one request, keeping the URL short
+)
+
+// GetAlerts retrieves the alerts in effect matching the filter without caching, since they change
+// throughout the day, and resolves the routes and stops they affect
+func (f *MbtaApiHelperImpl) GetAlerts(ctx context.Context, filter models.AlertFilter) ([]models.Alert, error) {
+	alerts, err := f.client.FetchAlerts(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	f.ResolveAlerts(ctx, alerts)
+	return alerts, nil
+}
+
+// ResolveAlerts fills in the routes, stops and modes affected by the alerts from their informed entities,
+// so it can serve as a data.AlertResolver. Routes are looked up in the in-memory route index and stop names
+// are cached, fetching the missing ones in as few requests as possible. Names that cannot be loaded are left empty.
+func (f *MbtaApiHelperImpl) ResolveAlerts(ctx context.Context, alerts []models.Alert) {
+	// Resolve what is known from the informed entities alone if the routes are unavailable
+	routes, err := f.routeIndex(ctx)
+	if err != nil {
+		log.Println("Failed to load routes to resolve alerts:", err)
+	}
+
+	// Collect the stops of every alert first, so their names are looked up together
+	var stopIDs []string
+	seen := make(map[string]bool)
+	for _, alert := range alerts {
+		for _, entity := range alert.InformedEntities {
+			if entity.StopID != "" && !seen[entity.StopID] {
+				stopIDs = append(stopIDs, entity.StopID)
+				seen[entity.StopID] = true
+			}
+		}
+	}
+	stopNames := f.stopNames(ctx, stopIDs)
+
+	for i := range alerts {
+		resolveAlert(&alerts[i], routes, stopNames)
+	}
+}
+
+// resolveAlert lists the routes, stops and modes named by the informed entities of an alert, once each
+// and in the order they are first named.
+func resolveAlert(alert *models.Alert, routes map[string]models.Route, stopNames map[string]string) {
+	alert.Routes = []models.AffectedRoute{}
+	alert.Stops = []models.AffectedStop{}
+	alert.Modes = []string{}
+
+	seen := make(map[string]bool) // Routes, stops and modes already listed, by prefixed ID
+	for _, entity := range alert.InformedEntities {
+		if entity.RouteID != "" && !seen["route:"+entity.RouteID] {
+			seen["route:"+entity.RouteID] = true
+			affected := models.AffectedRoute{ID: entity.RouteID}
+			if route, ok := routes[entity.RouteID]; ok {
+				affected.Name = route.Name
+				affected.Type = route.Type
+				affected.Mode = route.Mode
+				affected.Color = route.Color
+			} else if entity.RouteType != nil {
+				affected.Type = *entity.RouteType
+				affected.Mode = models.RouteTypeMode(*entity.RouteType)
+			}
+			alert.Routes = append(alert.Routes, affected)
+		}
+
+		if entity.StopID != "" && !seen["stop:"+entity.StopID] {
+			seen["stop:"+entity.StopID] = true
+			alert.Stops = append(alert.Stops, models.AffectedStop{ID: entity.StopID, Name: stopNames[entity.StopID]})
+		}
+
+		// An entity naming nothing but a route type applies to every route of that type
+		if entity.RouteType != nil && entity.RouteID == "" && entity.StopID == "" && entity.TripID == "" && entity.FacilityID == "" {
+			mode := models.RouteTypeMode(*entity.RouteType)
+			if !seen["mode:"+mode] {
+				seen["mode:"+mode] = true
+				alert.Modes = append(alert.Modes, mode)
+			}
+		}
+	}
+}
+
+// stopNames returns the names of the given stops keyed by stop ID, from the cache when possible.
+// Stops whose names cannot be loaded are missing from the result.
+func (f *MbtaApiHelperImpl) stopNames(ctx context.Context, stopIDs []string) map[string]string {
+	names := make(map[string]string, len(stopIDs))
+	if len(stopIDs) == 0 {
+		return names
+	}
+
+	keys := make([]string, len(stopIDs))
+	for i, stopID := range stopIDs {
+		keys[i] = stopNameCachePrefix + stopID
+	}
+	if items, err := f.cacheGetMulti(ctx, keys); err == nil {
+		for key, item := range items {
+			names[strings.TrimPrefix(key, stopNameCachePrefix)] = string(item.Value)
+		}
+	}
+
+	// Cache miss
+	var missing []string
+	for _, stopID := range stopIDs {
+		if _, ok := names[stopID]; !ok {
+			missing = append(missing, stopID)
+		}
+	}
+
+	var cacheErr error
+	for start := 0; start < len(missing) && ctx.Err() == nil; start += stopLookupBatchSize {
+		end := min(start+stopLookupBatchSize, len(missing))
+		stops, err := f.client.FetchStopsByID(ctx, missing[start:end])
+		if err != nil {
+			log.Println("Failed to fetch stop names:", err)
+			continue
+		}
+
+		// Cache the names, stops are renamed rarely enough to keep them indefinitely
+		for _, stop := range stops {
+			names[stop.ID] = stop.Attributes.Name
+			item := &memcache.Item{Key: stopNameCachePrefix + stop.ID, Value: []byte(stop.Attributes.Name)}
+			if err := f.cacheSet(ctx, item); err != nil {
+				cacheErr = err
+			}
+		}
+	}
+	if cacheErr != nil {
+		log.Println("Failed to cache data for stop names:", cacheErr)
+	}
+
+	return names
+}
@@ -0,0 +1,145 @@
+package usecases
+
+import (
+	"context"
+	"explorer/internal/core/domain/models"
+	"explorer/internal/ports/data"
+	"fmt"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+func TestResolveAlert(t *testing.T) {
+	bus, subway := models.RouteTypeBus, models.RouteTypeSubway
+	routes := map[string]models.Route{
+		"Red": {ID: "Red", Name: "Red Line", Type: subway, Mode: "subway", Color: "DA291C"},
+	}
+	stopNames := map[string]string{"place-pktrm": "Park Street"}
+
+	tests := []struct {
+		name     string
+		entities []models.InformedEntity
+		routes   []models.AffectedRoute
+		stops    []models.AffectedStop
+		modes    []string
+	}{
+		{
+			name:   "nothing named",
+			routes: []models.AffectedRoute{},
+			stops:  []models.AffectedStop{},
+			modes:  []string{},
+		},
+		{
+			name: "elevator at a station, named once",
+			entities: []models.InformedEntity{
+				{Activities: []string{"USING_WHEELCHAIR"}, RouteID: "Red", StopID: "place-pktrm", FacilityID: "804"},
+				{Activities: []string{"USING_WHEELCHAIR"}, RouteID: "Red", StopID: "place-pktrm", FacilityID: "805"},
+			},
+			routes: []models.AffectedRoute{{ID: "Red", Name: "Red Line", Type: subway, Mode: "subway", Color: "DA291C"}},
+			stops:  []models.AffectedStop{{ID: "place-pktrm", Name: "Park Street"}},
+			modes:  []string{},
+		},
+		{
+			name: "route and stop missing from the catalog",
+			entities: []models.InformedEntity{
+				{RouteID: "39", RouteType: &bus, StopID: "1234"},
+			},
+			routes: []models.AffectedRoute{{ID: "39", Type: bus, Mode: "bus"}},
+			stops:  []models.AffectedStop{{ID: "1234"}},
+			modes:  []string{},
+		},
+		{
+			name: "every route of a mode",
+			entities: []models.InformedEntity{
+				{RouteType: &bus},
+				{RouteType: &bus, RouteID: "39"},
+				{RouteType: &bus},
+			},
+			routes: []models.AffectedRoute{{ID: "39", Type: bus, Mode: "bus"}},
+			stops:  []models.AffectedStop{},
+			modes:  []string{"bus"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := models.Alert{InformedEntities: tt.entities}
+			resolveAlert(&alert, routes, stopNames)
+			if !reflect.DeepEqual(alert.Routes, tt.routes) {
+				t.Errorf("routes = %+v, want %+v", alert.Routes, tt.routes)
+			}
+			if !reflect.DeepEqual(alert.Stops, tt.stops) {
+				t.Errorf("stops = %+v, want %+v", alert.Stops, tt.stops)
+			}
+			if !reflect.DeepEqual(alert.Modes, tt.modes) {
+				t.Errorf("modes = %q, want %q", alert.Modes, tt.modes)
+			}
+		})
+	}
+}
+
+// stopsClient names every stop except "unknown", recording the stops asked for in each request
+type stopsClient struct {
+	data.MBTAClient // Other calls are not used
+	requests        [][]string
+}
+
+func (c *stopsClient) FetchStopsByID(ctx context.Context, stopIDs []string) ([]models.Stop, error) {
+	c.requests = append(c.requests, stopIDs)
+	var stops []models.Stop
+	for _, stopID := range stopIDs {
+		if stopID != "unknown" {
+			stops = append(stops, models.Stop{ID: stopID, Attributes: models.StopAttributes{Name: "Stop " + stopID}})
+		}
+	}
+	return stops, nil
+}
+
+func TestStopNames(t *testing.T) {
+	client := &stopsClient{}
+	helper := &MbtaApiHelperImpl{client: client, cache: memcache.New("127.0.0.1:1")}
+
+	if names := helper.stopNames(context.Background(), nil); len(names) != 0 || len(client.requests) != 0 {
+		t.Errorf("stopNames of no stops = %v after %d requests, want none", names, len(client.requests))
+	}
+
+	// The stops missing from the cache are looked up in batches
+	stopIDs := []string{"unknown"}
+	for i := range stopLookupBatchSize + 1 {
+		stopIDs = append(stopIDs, fmt.Sprint(i))
+	}
+	names := helper.stopNames(context.Background(), stopIDs)
+
+	if len(client.requests) != 2 || len(client.requests[0]) != stopLookupBatchSize || len(client.requests[1]) != 2 {
+		t.Errorf("looked up the stops in %d requests, want batches of %d", len(client.requests), stopLookupBatchSize)
+	}
+	if len(names) != stopLookupBatchSize+1 || names["0"] != "Stop 0" {
+		t.Errorf("got %d names, Stop 0 named %q, want every stop named", len(names), names["0"])
+	}
+	if name, ok := names["unknown"]; ok {
+		t.Errorf("unknown stop named %q, want it left out", name)
+	}
+}
+
+func TestAlertStreamURLIncludesEveryActivityByDefault(t *testing.T) {
+	tests := []struct {
+		activities []string
+		want       string
+	}{
+		{activities: nil, want: "ALL"},
+		{activities: []string{"USING_WHEELCHAIR", "BOARD"}, want: "BOARD,USING_WHEELCHAIR"},
+	}
+
+	for _, tt := range tests {
+		parsed, err := url.Parse(AlertStreamURL("https://api-v3.mbta.com", models.AlertFilter{Activities: tt.activities}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := parsed.Query().Get("filter[activity]"); got != tt.want {
+			t.Errorf("filter[activity] for %q = %q, want %q", tt.activities, got, tt.want)
+		}
+	}
+}
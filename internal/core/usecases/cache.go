@@ -0,0 +1,69 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// maxCacheItemSize is the largest value stored in the cache. Memcache rejects items over 1 MB by default,
+// key and item overhead included, so larger values are not sent at all.
+const maxCacheItemSize = 1000 * 1000
+
+// The memcache client does not take a context, so these wrap its calls to give up with the context's
+// error once the context is done. A call given up on still completes in the background, bounded by
+// the client's own socket timeout.
+
+// cacheGet retrieves an item from the cache, failing with memcache.ErrCacheMiss if there is none
+func (f *MbtaApiHelperImpl) cacheGet(ctx context.Context, key string) (*memcache.Item, error) {
+	return withContext(ctx, func() (*memcache.Item, error) {
+		return f.cache.Get(key)
+	})
+}
+
+// cacheGetMulti retrieves the items found in the cache for the given keys, keyed by key
+func (f *MbtaApiHelperImpl) cacheGetMulti(ctx context.Context, keys []string) (map[string]*memcache.Item, error) {
+	return withContext(ctx, func() (map[string]*memcache.Item, error) {
+		return f.cache.GetMulti(keys)
+	})
+}
+
+// cacheSet stores an item in the cache, failing without contacting the cache if its value is too large
+func (f *MbtaApiHelperImpl) cacheSet(ctx context.Context, item *memcache.Item) error {
+	if len(item.Value) > maxCacheItemSize {
+		return fmt.Errorf("cache item %s is too large: %d bytes", item.Key, len(item.Value))
+	}
+	_, err := withContext(ctx, func() (struct{}, error) {
+		return struct{}{}, f.cache.Set(item)
+	})
+	return err
+}
+
+// withContext runs call, returning its result, or the context's error if ctx is done first.
+// Only the wait is cancelled: the memcache call itself cannot be, since the client takes no context,
+// so it keeps running until it returns or times out, and its result is then discarded.
+func withContext[T any](ctx context.Context, call func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	// Buffered, so the call can complete after it is given up on
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := call()
+		done <- result{value, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
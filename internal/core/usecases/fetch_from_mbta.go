@@ -1,17 +1,26 @@
 package usecases
 
 import (
+	"context"
 	"encoding/json"
 	"explorer/internal/core/domain/models"
 	"explorer/internal/ports/data"
 	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
 )
 
+// routesCacheExpiration is how long the route catalog is cached
+const routesCacheExpiration = 24 * time.Hour
+
 type MbtaApiHelperImpl struct {
 	client data.MBTAClient  // The client used to fetch data from the MBTA API
 	cache  *memcache.Client // Cache client for storing and retrieving data
+	routes routeIndex       // Every route, loaded on first use
 }
 
 // NewMbtaApiHelper initializes fetchFromMBTAUseCaseImpl with a client and cache
@@ -20,9 +29,9 @@ func NewMbtaApiHelper(client data.MBTAClient, cache *memcache.Client) MbtaApiHel
 }
 
 // GetStops retrieves a list of stops for the given routeID with caching
-func (f *MbtaApiHelperImpl) GetStops(routeID string) ([]models.Stop, error) {
+func (f *MbtaApiHelperImpl) GetStops(ctx context.Context, routeID string) ([]models.Stop, error) {
 	cacheKey := "stops:" + routeID
-	item, err := f.cache.Get(cacheKey)
+	item, err := f.cacheGet(ctx, cacheKey)
 	if err == nil {
 		log.Println("Cache hit for GetStops:", routeID)
 		var stops []models.Stop
@@ -33,14 +42,14 @@ func (f *MbtaApiHelperImpl) GetStops(routeID string) ([]models.Stop, error) {
 	}
 
 	// Cache miss or unmarshalling failure
-	stops, err := f.client.FetchStops(routeID)
+	stops, err := f.client.FetchStops(ctx, routeID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Cache the result
 	value, _ := json.Marshal(stops)
-	if err := f.cache.Set(&memcache.Item{Key: cacheKey, Value: value}); err != nil {
+	if err := f.cacheSet(ctx, &memcache.Item{Key: cacheKey, Value: value}); err != nil {
 		log.Println("Failed to cache data for GetStops:", err)
 	}
 
@@ -48,9 +57,9 @@ func (f *MbtaApiHelperImpl) GetStops(routeID string) ([]models.Stop, error) {
 }
 
 // GetShapes retrieves a list of decoded coordinates for the given routeID with caching
-func (f *MbtaApiHelperImpl) GetShapes(routeID string) (models.DecodedRouteShape, error) {
+func (f *MbtaApiHelperImpl) GetShapes(ctx context.Context, routeID string) (models.DecodedRouteShape, error) {
 	cacheKey := "shapes:" + routeID
-	item, err := f.cache.Get(cacheKey)
+	item, err := f.cacheGet(ctx, cacheKey)
 	if err == nil {
 		log.Println("Cache hit for GetShapes:", routeID)
 		var shapes models.DecodedRouteShape
@@ -62,21 +71,82 @@ func (f *MbtaApiHelperImpl) GetShapes(routeID string) (models.DecodedRouteShape,
 	}
 
 	// Cache miss or unmarshalling failure
-	shapes, err := f.client.FetchShapes(routeID)
+	shapes, err := f.client.FetchShapes(ctx, routeID)
 	if err != nil {
 		return models.DecodedRouteShape{}, err
 	}
 
 	// Cache the result
 	value, _ := json.Marshal(shapes)
-	if err := f.cache.Set(&memcache.Item{Key: cacheKey, Value: value}); err != nil {
+	if err := f.cacheSet(ctx, &memcache.Item{Key: cacheKey, Value: value}); err != nil {
 		log.Println("Failed to cache data for GetShapes:", err)
 	}
 
 	return shapes, nil
 }
 
-// GetLiveData retrieves live vehicle data for the given routeID without caching
-func (f *MbtaApiHelperImpl) GetLiveData(routeID string) ([]models.Vehicle, error) {
-	return f.client.FetchLiveData(routeID)
+// GetRoutes retrieves the routes of the given route types, or every route if routeTypes is empty, with caching.
+// Routes rarely change, so they are cached for routesCacheExpiration rather than indefinitely.
+func (f *MbtaApiHelperImpl) GetRoutes(ctx context.Context, routeTypes []int) ([]models.Route, error) {
+	cacheKey := routesCacheKey(routeTypes)
+	item, err := f.cacheGet(ctx, cacheKey)
+	if err == nil {
+		log.Println("Cache hit for GetRoutes:", cacheKey)
+		var routes []models.Route
+		if err := json.Unmarshal(item.Value, &routes); err == nil {
+			return routes, nil
+		}
+		log.Println("Failed to unmarshal cached data, fetching fresh data.")
+	}
+
+	// Cache miss or unmarshalling failure
+	routes, err := f.client.FetchRoutes(ctx, routeTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the result
+	value, _ := json.Marshal(routes)
+	item = &memcache.Item{Key: cacheKey, Value: value, Expiration: int32(routesCacheExpiration.Seconds())}
+	if err := f.cacheSet(ctx, item); err != nil {
+		log.Println("Failed to cache data for GetRoutes:", err)
+	}
+
+	return routes, nil
+}
+
+// routesCacheKey returns the cache key of the routes of the given route types, e.g. "routes:2,3".
+// The route types are sorted and deduplicated, so the same routes share one key however they are requested.
+func routesCacheKey(routeTypes []int) string {
+	if len(routeTypes) == 0 {
+		return "routes:all"
+	}
+	sorted := slices.Compact(slices.Sorted(slices.Values(routeTypes)))
+	types := make([]string, len(sorted))
+	for i, routeType := range sorted {
+		types[i] = strconv.Itoa(routeType)
+	}
+	return "routes:" + strings.Join(types, ",")
+}
+
+// GetLiveData retrieves live vehicle data for the given routeID and/or route types without caching,
+// recording the route type and mode of each vehicle
+func (f *MbtaApiHelperImpl) GetLiveData(ctx context.Context, routeID string, routeTypes []int) ([]models.Vehicle, error) {
+	vehicles, err := f.client.FetchLiveData(ctx, routeID, routeTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range vehicles {
+		if routeType, ok := f.RouteType(ctx, vehicles[i].Route); ok {
+			vehicles[i].SetRouteType(routeType)
+		}
+	}
+	return vehicles, nil
+}
+
+// GetPredictions retrieves the predictions for the given stop IDs and/or route IDs without caching,
+// since they change with every vehicle movement
+func (f *MbtaApiHelperImpl) GetPredictions(ctx context.Context, stopID, routeID string) ([]models.Prediction, error) {
+	return f.client.FetchPredictions(ctx, stopID, routeID)
 }
@@ -0,0 +1,41 @@
+package usecases
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestRoutesCacheKey(t *testing.T) {
+	tests := []struct {
+		routeTypes []int
+		want       string
+	}{
+		{routeTypes: nil, want: "routes:all"},
+		{routeTypes: []int{3}, want: "routes:3"},
+		{routeTypes: []int{2, 3}, want: "routes:2,3"},
+		{routeTypes: []int{3, 2}, want: "routes:2,3"},
+		{routeTypes: []int{3, 2, 3, 2}, want: "routes:2,3"},
+	}
+
+	for _, tt := range tests {
+		if got := routesCacheKey(tt.routeTypes); got != tt.want {
+			t.Errorf("routesCacheKey(%v) = %q, want %q", tt.routeTypes, got, tt.want)
+		}
+	}
+}
+
+func TestStreamURLsEscapeIDs(t *testing.T) {
+	streamURL := PredictionStreamURL("https://api-v3.mbta.com", []string{"place-pktrm&filter[route]=Orange"}, []string{"Red"})
+
+	parsed, err := url.Parse(streamURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if got := query.Get("filter[stop]"); got != "place-pktrm&filter[route]=Orange" {
+		t.Errorf("filter[stop] = %q, want the stop ID unchanged", got)
+	}
+	if got := query["filter[route]"]; len(got) != 1 || got[0] != "Red" {
+		t.Errorf("filter[route] = %q, want only Red", got)
+	}
+}
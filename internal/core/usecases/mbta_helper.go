@@ -1,16 +1,44 @@
 package usecases
 
-import "explorer/internal/core/domain/models"
+import (
+	"context"
+	"explorer/internal/core/domain/models"
+)
 
 // @TODO should this be in ports somewhere?
-// MbtaApiHelper is an interface that defines the methods for fetching stops and live vehicle data
+// MbtaApiHelper is an interface that defines the methods for fetching stops, routes, trips, live vehicle data, predictions, schedules and alerts
+// Every method gives up, failing with the context's error where it returns one, as soon as its context is done
 type MbtaApiHelper interface {
 	// GetStops fetches a list of stops for a given route ID
-	GetStops(routeID string) ([]models.Stop, error)
+	GetStops(ctx context.Context, routeID string) ([]models.Stop, error)
 
 	// GetShapes fetches a list of decoded coordinates for a given route ID
-	GetShapes(routeID string) (models.DecodedRouteShape, error)
+	GetShapes(ctx context.Context, routeID string) (models.DecodedRouteShape, error)
 
-	// GetLiveData fetches live vehicle data for a given route ID
-	GetLiveData(routeID string) ([]models.Vehicle, error)
+	// GetRoutes fetches the route catalog, optionally restricted to the given route types
+	GetRoutes(ctx context.Context, routeTypes []int) ([]models.Route, error)
+
+	// GetLiveData fetches live vehicle data for the given route IDs and/or route types
+	GetLiveData(ctx context.Context, routeID string, routeTypes []int) ([]models.Vehicle, error)
+
+	// GetPredictions fetches the predictions for the given stop IDs and/or route IDs
+	GetPredictions(ctx context.Context, stopID, routeID string) ([]models.Prediction, error)
+
+	// GetSchedules fetches the schedules of a service date (today's if unset, unless filtering by trip) matching the filter
+	GetSchedules(ctx context.Context, filter models.ScheduleFilter) ([]models.Schedule, error)
+
+	// GetTrip fetches a trip with its stops, scheduled and predicted times, vehicle and shape
+	GetTrip(ctx context.Context, tripID string) (models.TripDetail, error)
+
+	// GetAlerts fetches the alerts in effect matching the filter, with the routes and stops they affect
+	GetAlerts(ctx context.Context, filter models.AlertFilter) ([]models.Alert, error)
+
+	// ResolveAlerts fills in the routes, stops and modes affected by alerts, so it can serve as a data.AlertResolver
+	ResolveAlerts(ctx context.Context, alerts []models.Alert)
+
+	// GetRouteIDs lists the IDs of the routes of the given route types
+	GetRouteIDs(ctx context.Context, routeTypes []int) ([]string, error)
+
+	// RouteType looks up the route type of a route, so it can serve as a data.RouteTypeResolver
+	RouteType(ctx context.Context, routeID string) (int, bool)
 }
@@ -0,0 +1,127 @@
+package usecases
+
+import (
+	"context"
+	"explorer/internal/core/domain/models"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	routeIndexRefreshInterval = 24 * time.Hour   // How often the routes are reloaded from the MBTA API
+	routeIndexRetryInterval   = time.Minute      // How long to wait before retrying after failing to load them
+	routeIndexLoadTimeout     = 15 * time.Second // How long a load may take, whoever is waiting for it
+)
+
+// routeIndex holds every MBTA route in memory, for lookups made on every streamed event. Routes
+// rarely change, so the whole catalog is loaded on first use and refreshed once a day.
+type routeIndex struct {
+	mutex    sync.Mutex
+	routes   map[string]models.Route // Routes by ID
+	loadedAt time.Time               // When routes was last loaded
+	failedAt time.Time               // When loading last failed, to avoid hammering the API
+	err      error                   // Why loading last failed
+	loading  chan struct{}           // Closed when the load in progress ends, nil if there is none
+}
+
+// RouteType returns the route type of the given route, and false if it is unknown
+// or the routes could not be loaded.
+func (f *MbtaApiHelperImpl) RouteType(ctx context.Context, routeID string) (int, bool) {
+	routes, err := f.routeIndex(ctx)
+	if err != nil {
+		return 0, false
+	}
+	route, ok := routes[routeID]
+	return route.Type, ok
+}
+
+// GetRouteIDs returns the IDs of every route of the given route types, sorted
+func (f *MbtaApiHelperImpl) GetRouteIDs(ctx context.Context, routeTypes []int) ([]string, error) {
+	routes, err := f.routeIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var routeIDs []string
+	for routeID, route := range routes {
+		for _, wanted := range routeTypes {
+			if route.Type == wanted {
+				routeIDs = append(routeIDs, routeID)
+				break
+			}
+		}
+	}
+	sort.Strings(routeIDs)
+	return routeIDs, nil
+}
+
+// routeIndex returns every route keyed by ID, loading them from the route catalog if they have not
+// been loaded yet or are due for a refresh. The catalog is fetched in the background without holding
+// the lock, once however many callers need it: a stale index is served straight away while it is
+// refreshed, and kept if the refresh fails. Only the first load is waited for, until ctx is done.
+func (f *MbtaApiHelperImpl) routeIndex(ctx context.Context) (map[string]models.Route, error) {
+	index := &f.routes
+	index.mutex.Lock()
+	routes := index.routes
+	due := routes == nil || time.Since(index.loadedAt) >= routeIndexRefreshInterval
+	if due && index.loading == nil && time.Since(index.failedAt) >= routeIndexRetryInterval {
+		index.loading = make(chan struct{})
+		go f.loadRouteIndex(ctx, index.loading)
+	}
+	loading, failedAt, loadErr := index.loading, index.failedAt, index.err
+	index.mutex.Unlock()
+
+	if routes != nil {
+		return routes, nil
+	}
+	if loading == nil {
+		return nil, fmt.Errorf("routes are unavailable, retrying in %s: %w", (routeIndexRetryInterval - time.Since(failedAt)).Round(time.Second), loadErr)
+	}
+
+	// Nothing to serve until the first load ends
+	select {
+	case <-loading:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	if index.routes == nil {
+		return nil, index.err
+	}
+	return index.routes, nil
+}
+
+// loadRouteIndex loads the route catalog into the index, closing done once it is stored or the load
+// failed. The load is shared by every caller waiting for it, so it is not cancelled with the context of
+// the caller that started it, only bounded by routeIndexLoadTimeout.
+func (f *MbtaApiHelperImpl) loadRouteIndex(ctx context.Context, done chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), routeIndexLoadTimeout)
+	defer cancel()
+	catalog, err := f.GetRoutes(ctx, nil)
+
+	index := &f.routes
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	defer close(done) // Wake the waiting callers once the result is stored
+	index.loading = nil
+
+	if err != nil {
+		log.Println("Failed to load routes:", err)
+		index.failedAt = time.Now()
+		index.err = err
+		return
+	}
+
+	routes := make(map[string]models.Route, len(catalog))
+	for _, route := range catalog {
+		routes[route.ID] = route
+	}
+	index.routes = routes
+	index.loadedAt = time.Now()
+	index.err = nil
+}
@@ -0,0 +1,84 @@
+package usecases
+
+import (
+	"context"
+	"explorer/internal/core/domain/models"
+	"explorer/internal/ports/data"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// blockingRoutesClient serves the route catalog once release is closed, counting the fetches
+type blockingRoutesClient struct {
+	data.MBTAClient // Other calls are not used
+	release         chan struct{}
+	fetches         atomic.Int32
+}
+
+func (c *blockingRoutesClient) FetchRoutes(ctx context.Context, routeTypes []int) ([]models.Route, error) {
+	c.fetches.Add(1)
+	select {
+	case <-c.release:
+		return []models.Route{{ID: "Red", Type: 1}}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// newBlockingHelper returns a helper fetching routes from a blocking client, with no cache reachable
+func newBlockingHelper() (*MbtaApiHelperImpl, *blockingRoutesClient) {
+	client := &blockingRoutesClient{release: make(chan struct{})}
+	return &MbtaApiHelperImpl{client: client, cache: memcache.New("127.0.0.1:1")}, client
+}
+
+func TestRouteIndexSharesFirstLoad(t *testing.T) {
+	helper, client := newBlockingHelper()
+
+	// A caller giving up does not cancel the load for the others
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, ok := helper.RouteType(ctx, "Red"); ok {
+		t.Fatal("route type known before the routes were loaded")
+	}
+
+	results := make(chan bool, 3)
+	for range 3 {
+		go func() {
+			routeType, ok := helper.RouteType(context.Background(), "Red")
+			results <- ok && routeType == 1
+		}()
+	}
+	close(client.release)
+	for range 3 {
+		if !<-results {
+			t.Error("waiting caller did not get the loaded route type")
+		}
+	}
+	if fetches := client.fetches.Load(); fetches != 1 {
+		t.Errorf("fetched the routes %d times, want once", fetches)
+	}
+}
+
+func TestRouteIndexServesStaleRoutesWhileRefreshing(t *testing.T) {
+	helper, client := newBlockingHelper()
+	helper.routes.routes = map[string]models.Route{"Red": {ID: "Red", Type: 1}}
+	helper.routes.loadedAt = time.Now().Add(-routeIndexRefreshInterval)
+
+	// The refresh is blocked, so the stale routes are served without waiting for it
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if routeType, ok := helper.RouteType(context.Background(), "Red"); !ok || routeType != 1 {
+			t.Errorf("RouteType(Red) = %d, %v, want the stale route type", routeType, ok)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RouteType blocked on the refresh")
+	}
+	close(client.release)
+}
@@ -0,0 +1,85 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"explorer/internal/core/domain/models"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Embed the time zone database, so the MBTA time zone is known on hosts without one
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+const (
+	schedulesCacheExpiration = 24 * time.Hour // How long the schedules of a service date are cached
+	serviceDayStartHour      = 3              // Hour a new service day starts, trips after midnight belonging to the previous one
+)
+
+// mbtaLocation is the time zone MBTA service dates are counted in
+var mbtaLocation = mustLoadLocation("America/New_York")
+
+// GetSchedules retrieves the schedules matching the filter, for the current service date if filter.Date is empty,
+// with caching. The schedules of trips are not narrowed to a date unless one is given, since a trip may not run today. The schedules of a service date rarely change, so they are cached per date for schedulesCacheExpiration,
+// unless there are too many to fit in a cache item, e.g. every stop of a busy route.
+func (f *MbtaApiHelperImpl) GetSchedules(ctx context.Context, filter models.ScheduleFilter) ([]models.Schedule, error) {
+	if filter.Date == "" && filter.TripID == "" {
+		filter.Date = serviceDate(time.Now())
+	}
+
+	cacheKey := schedulesCacheKey(filter)
+	item, err := f.cacheGet(ctx, cacheKey)
+	if err == nil {
+		log.Println("Cache hit for GetSchedules:", cacheKey)
+		var schedules []models.Schedule
+		if err := json.Unmarshal(item.Value, &schedules); err == nil {
+			return schedules, nil
+		}
+		log.Println("Failed to unmarshal cached data, fetching fresh data.")
+	}
+
+	// Cache miss or unmarshalling failure
+	schedules, err := f.client.FetchSchedules(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the result
+	value, _ := json.Marshal(schedules)
+	item = &memcache.Item{Key: cacheKey, Value: value, Expiration: int32(schedulesCacheExpiration.Seconds())}
+	if err := f.cacheSet(ctx, item); err != nil {
+		log.Println("Failed to cache data for GetSchedules:", err)
+	}
+
+	return schedules, nil
+}
+
+// schedulesCacheKey returns the cache key of the schedules matching the filter, e.g. "schedules:9b2e...".
+// The filter comes from the request, so it is hashed to keep the key within the characters and length memcache allows.
+func schedulesCacheKey(filter models.ScheduleFilter) string {
+	direction := ""
+	if filter.DirectionID != nil {
+		direction = strconv.Itoa(*filter.DirectionID)
+	}
+	hash := sha256.Sum256([]byte(strings.Join([]string{filter.Date, filter.RouteID, filter.StopID, filter.TripID, direction}, "\x00")))
+	return "schedules:" + hex.EncodeToString(hash[:])
+}
+
+// serviceDate returns the MBTA service date at t as YYYY-MM-DD. Service runs past midnight, so
+// before serviceDayStartHour in Boston it is still the previous day's service.
+func serviceDate(t time.Time) string {
+	return t.In(mbtaLocation).Add(-serviceDayStartHour * time.Hour).Format(time.DateOnly)
+}
+
+// mustLoadLocation loads the named time zone, panicking if it is unknown
+func mustLoadLocation(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return location
+}
@@ -0,0 +1,44 @@
+package usecases
+
+import (
+	"context"
+	"explorer/internal/core/domain/models"
+	"strings"
+	"testing"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+func TestSchedulesCacheKey(t *testing.T) {
+	zero, one := 0, 1
+	filters := []models.ScheduleFilter{
+		{Date: "2025-01-12", RouteID: "Red"},
+		{Date: "2025-01-12", RouteID: "Red", DirectionID: &zero},
+		{Date: "2025-01-12", RouteID: "Red", DirectionID: &one},
+		{Date: "2025-01-12", StopID: "Red"},
+		{Date: "2025-01-13", RouteID: "Red"},
+		{Date: "2025-01-12", RouteID: "Red Line\r\n" + strings.Repeat("x", 300)},
+	}
+
+	seen := make(map[string]models.ScheduleFilter)
+	for _, filter := range filters {
+		key := schedulesCacheKey(filter)
+		if len(key) > 250 || strings.ContainsFunc(key, func(r rune) bool { return r <= ' ' || r == 0x7f }) {
+			t.Errorf("schedulesCacheKey(%+v) = %q, not a valid memcache key", filter, key)
+		}
+		if other, ok := seen[key]; ok {
+			t.Errorf("filters %+v and %+v share the cache key %q", filter, other, key)
+		}
+		seen[key] = filter
+	}
+}
+
+func TestCacheSetRejectsLargeItems(t *testing.T) {
+	helper := &MbtaApiHelperImpl{cache: memcache.New("127.0.0.1:1")}
+	item := &memcache.Item{Key: "schedules:large", Value: make([]byte, maxCacheItemSize+1)}
+
+	err := helper.cacheSet(context.Background(), item)
+	if err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("cacheSet of %d bytes error = %v, want it rejected as too large", len(item.Value), err)
+	}
+}
@@ -0,0 +1,48 @@
+package usecases
+
+import (
+	"context"
+	"explorer/internal/core/domain/models"
+	ports "explorer/internal/ports/streaming"
+)
+
+// StreamUseCase attaches clients to a shared upstream stream, whatever resources (e.g. vehicles,
+// predictions or alerts) it carries. Streams needing more than this embed it, see StreamVehiclesUseCase.
+type StreamUseCase struct {
+	streamManager ports.StreamManager
+}
+
+func NewStreamUseCase(sm ports.StreamManager) *StreamUseCase {
+	return &StreamUseCase{
+		streamManager: sm,
+	}
+}
+
+// StreamSetup initializes the stream and returns a client channel configured with the given options.
+// The client is primed with the current resources unless it can resume from opts.LastEventID.
+func (uc *StreamUseCase) StreamSetup(url, apiKey string, opts ports.ClientOptions) chan models.StreamEvent {
+	// Ensure the stream is running
+	uc.streamManager.EnsureStreaming(url, apiKey)
+
+	// Create and register client channel, priming it with the current resources
+	clientChan := make(chan models.StreamEvent, 100)
+	opts.Snapshot = uc.snapshotEvent
+	uc.streamManager.AddClient(clientChan, opts)
+
+	return clientChan
+}
+
+// snapshotEvent synthesizes a reset event containing every resource currently known,
+// so clients joining after the upstream reset still receive the full set.
+// It returns nil until the upstream reset has been received, and an empty reset when there are no resources.
+func (uc *StreamUseCase) snapshotEvent() models.StreamEvent {
+	return uc.streamManager.Snapshot()
+}
+
+// HandleDisconnect sets up disconnection handling for a client
+func (uc *StreamUseCase) HandleDisconnect(ctx context.Context, clientChan chan models.StreamEvent) {
+	go func() {
+		<-ctx.Done()
+		uc.streamManager.RemoveClient(clientChan)
+	}()
+}
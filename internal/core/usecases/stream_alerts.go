@@ -0,0 +1,28 @@
+package usecases
+
+import (
+	"explorer/internal/constants"
+	"explorer/internal/core/domain/models"
+	"sort"
+)
+
+// AlertStreamURL returns the URL of the upstream stream of active alerts matching the filter on the
+// API at baseURL. Alerts are not filtered per client, so every distinct filter gets its own stream,
+// shared by every client asking for the same alerts. Without activities, alerts affecting any activity
+// are streamed, since the MBTA API would otherwise leave out elevator and escalator outages.
+func AlertStreamURL(baseURL string, filter models.AlertFilter) string {
+	// Canonical order, so equal filters share a stream
+	routeIDs := append([]string{}, filter.RouteIDs...)
+	sort.Strings(routeIDs)
+	stopIDs := append([]string{}, filter.StopIDs...)
+	sort.Strings(stopIDs)
+	activities := append([]string{}, filter.Activities...)
+	sort.Strings(activities)
+	if len(activities) == 0 {
+		activities = []string{"ALL"} // The MBTA API defaults to BOARD, EXIT and RIDE
+	}
+	severities := append([]int{}, filter.Severities...)
+	sort.Ints(severities)
+
+	return constants.AlertStreamUrl(baseURL, routeIDs, stopIDs, activities, severities)
+}
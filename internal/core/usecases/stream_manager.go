@@ -2,87 +2,270 @@ package usecases
 
 import (
 	"context"
+	"explorer/internal/core/domain/models"
 	ports "explorer/internal/ports/streaming"
 	"log"
-	"os"
-	"os/signal"
 	"sync"
-	"syscall"
 	"time"
 )
 
+// StreamManagerUseCase owns the lifecycle of the upstream stream. The upstream connection is
+// started when the first client joins, kept warm for a grace period after the last client
+// leaves, then cancelled. It is started again when a new client joins.
 type StreamManagerUseCase struct {
+	url         string // Upstream URL, set when the stream is first started
 	source      ports.StreamSource
 	distributor ports.StreamDistributor
-	cancelFunc  context.CancelFunc
+	store       ports.StreamStore
+	gracePeriod time.Duration // How long to keep the upstream open without clients
+
+	mutex      sync.Mutex                           // Guards the fields below
+	clients    map[chan models.StreamEvent]struct{} // Clients currently attached, used for reference counting
+	holders    int                                  // Callers holding the manager until they have added their client
+	running    bool                                 // Whether the upstream stream is running
+	cancelFunc context.CancelFunc                   // Cancels the running upstream stream
+	stopped    <-chan struct{}                      // Closed once the running upstream stream has stopped reading
+	stopping   chan struct{}                        // Closed once the last stopped stream has exited and its state was cleared
+	idleTimer  *time.Timer                          // Fires when the grace period after the last client ends
+	idleGen    uint64                               // Incremented whenever the idle timer is scheduled or cancelled
 }
 
-func NewStreamManagerUseCase(source ports.StreamSource, Distributor ports.StreamDistributor) *StreamManagerUseCase {
+func NewStreamManagerUseCase(source ports.StreamSource, Distributor ports.StreamDistributor, store ports.StreamStore, gracePeriod time.Duration) *StreamManagerUseCase {
 	return &StreamManagerUseCase{
 		source:      source,
 		distributor: Distributor,
+		store:       store,
+		gracePeriod: gracePeriod,
+		clients:     make(map[chan models.StreamEvent]struct{}),
 	}
 }
 
-var streamOnce sync.Once
-
+// EnsureStreaming starts the upstream stream if it is not already running and cancels
+// any pending idle shutdown. It is called before a client is added.
 func (sm *StreamManagerUseCase) EnsureStreaming(url, apiKey string) {
-	streamOnce.Do(func() {
-		log.Println("Ensuring streaming is started...")
-		// Create a new context with cancellation support
-		ctx, cancel := context.WithCancel(context.Background())
-		sm.cancelFunc = cancel // Store the cancel function
-
-		// Start streaming MBTA data in a separate goroutine
-		go func() {
-			// Check if the API key is provided, if not, log an error and stop
-			if apiKey == "" {
-				log.Fatal("MBTA_API_KEY environment variable not set")
-			}
-
-			// Start the MBTA stream with the provided URL and API key
-			sm.source.Start(ctx, url, apiKey)
-		}()
-
-		// Handle system shutdown signals (e.g., SIGINT, SIGTERM) in a separate goroutine
-		go func() {
-			// Create a channel to receive shutdown signals
-			sigChan := make(chan os.Signal, 1)
-			// Notify the channel for interrupt (Ctrl+C) or termination signals
-			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-			// Wait for a signal to shut down the stream
-			<-sigChan
-			// Cancel the context to stop the streaming process
-			cancel()
-			// Allow a brief moment for goroutines to clean up
-			time.Sleep(1 * time.Second)
-			// Exit the program
-			os.Exit(0)
-		}()
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	sm.cancelIdleTimer()
+
+	// Wait for a stream that is still stopping, so clearing its state cannot wipe out the new stream's
+	for !sm.running && sm.stopping != nil {
+		stopping := sm.stopping
+		sm.mutex.Unlock()
+		<-stopping
+		sm.mutex.Lock()
+		if sm.stopping == stopping {
+			sm.stopping = nil
+		}
+	}
+	if sm.running {
+		return
+	}
+	sm.url = url
+
+	log.Println("Starting upstream stream...")
+	// Create a new context with cancellation support
+	ctx, cancel := context.WithCancel(context.Background())
+	sm.cancelFunc = cancel // Store the cancel function
+	sm.running = true
+
+	// Start the MBTA stream with the provided URL and API key
+	sm.stopped = sm.source.Start(ctx, url, apiKey)
+}
+
+// Shutdown stops the upstream stream immediately, regardless of attached clients.
+func (sm *StreamManagerUseCase) Shutdown() {
+	sm.mutex.Lock()
+	sm.cancelIdleTimer()
+	wait := sm.stopStreaming()
+	sm.mutex.Unlock()
+
+	wait()
+}
+
+// Idle reports whether the upstream stream is stopped, no clients are attached and nobody holds the manager.
+func (sm *StreamManagerUseCase) Idle() bool {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	return !sm.running && len(sm.clients) == 0 && sm.holders == 0
+}
+
+// hold keeps the manager from being found idle until the returned release function is called.
+// Calling release more than once has no further effect.
+func (sm *StreamManagerUseCase) hold() func() {
+	sm.mutex.Lock()
+	sm.holders++
+	sm.mutex.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			sm.mutex.Lock()
+			sm.holders--
+			sm.mutex.Unlock()
+		})
+	}
+}
+
+// scheduleIdleStop starts the grace period after the last client has left.
+// The caller must hold the mutex.
+func (sm *StreamManagerUseCase) scheduleIdleStop() {
+	sm.cancelIdleTimer()
+	gen := sm.idleGen
+	sm.idleTimer = time.AfterFunc(sm.gracePeriod, func() {
+		sm.mutex.Lock()
+
+		// Ignore timers that were cancelled or replaced after firing, and keep the stream for callers about to add a client
+		if gen != sm.idleGen || len(sm.clients) > 0 || sm.holders > 0 {
+			sm.mutex.Unlock()
+			return
+		}
+		log.Printf("No clients for %s, stopping upstream stream", sm.gracePeriod)
+		wait := sm.stopStreaming()
+		sm.mutex.Unlock()
+
+		wait()
 	})
 }
 
+// cancelIdleTimer cancels a pending idle shutdown, if any. The caller must hold the mutex.
+func (sm *StreamManagerUseCase) cancelIdleTimer() {
+	sm.idleGen++
+	if sm.idleTimer != nil {
+		sm.idleTimer.Stop()
+		sm.idleTimer = nil
+	}
+}
+
+// stopStreaming cancels the upstream stream. The caller must hold the mutex, then release it and
+// call the returned function, which waits for the source to stop and clears the stream state, as it
+// would otherwise go stale until the stream is restarted. The state is only cleared once the source
+// has stopped, so an event it was still applying cannot repopulate it.
+func (sm *StreamManagerUseCase) stopStreaming() (wait func()) {
+	if !sm.running {
+		return func() {}
+	}
+	sm.cancelFunc()
+	stopped := sm.stopped
+	stopping := make(chan struct{})
+	sm.cancelFunc = nil
+	sm.stopped = nil
+	sm.stopping = stopping
+	sm.running = false
+
+	return func() {
+		<-stopped
+		sm.store.Clear()
+		close(stopping)
+	}
+}
+
+// Status reports the state of the upstream stream, its clients and the counters
+// collected by the underlying StreamSource and StreamDistributor
+func (sm *StreamManagerUseCase) Status() models.StreamStatus {
+	sm.mutex.Lock()
+	status := models.StreamStatus{
+		URL:     sm.url,
+		Running: sm.running,
+		Clients: len(sm.clients),
+	}
+	sm.mutex.Unlock()
+
+	status.Source = sm.source.SourceStats()
+	status.Distributor = sm.distributor.DistributorStats()
+	return status
+}
+
 // Start delegates to the underlying StreamSource
-func (sm *StreamManagerUseCase) Start(ctx context.Context, url, apiKey string) {
-	sm.source.Start(ctx, url, apiKey) // Delegate to the actual StreamDistributor
+func (sm *StreamManagerUseCase) Start(ctx context.Context, url, apiKey string) <-chan struct{} {
+	return sm.source.Start(ctx, url, apiKey) // Delegate to the actual StreamSource
+}
+
+// State delegates to the underlying StreamSource
+func (sm *StreamManagerUseCase) State() ports.ConnectionState {
+	return sm.source.State()
+}
+
+// SourceStats delegates to the underlying StreamSource
+func (sm *StreamManagerUseCase) SourceStats() models.StreamSourceStats {
+	return sm.source.SourceStats()
+}
+
+// DistributorStats delegates to the underlying StreamDistributor
+func (sm *StreamManagerUseCase) DistributorStats() models.StreamDistributorStats {
+	return sm.distributor.DistributorStats()
+}
+
+// AddClient delegates to the underlying StreamDistributor and counts the client
+func (sm *StreamManagerUseCase) AddClient(client chan models.StreamEvent, opts ports.ClientOptions) {
+	sm.mutex.Lock()
+	sm.clients[client] = struct{}{}
+	sm.cancelIdleTimer()
+	sm.mutex.Unlock()
+
+	sm.distributor.AddClient(client, opts) // Delegate to the actual StreamDistributor
 }
 
-// AddClient delegates to the underlying StreamDistributor
-func (sm *StreamManagerUseCase) AddClient(client chan string) {
-	sm.distributor.AddClient(client) // Delegate to the actual StreamDistributor
+// UpdateClient delegates to the underlying StreamDistributor
+func (sm *StreamManagerUseCase) UpdateClient(client chan models.StreamEvent, opts ports.ClientOptions) {
+	sm.distributor.UpdateClient(client, opts) // Delegate to the actual StreamDistributor
 }
 
-// RemoveClient delegates to the underlying StreamDistributor
-func (sm *StreamManagerUseCase) RemoveClient(client chan string) {
+// RemoveClient delegates to the underlying StreamDistributor and schedules the upstream
+// stream to stop once the last client has left
+func (sm *StreamManagerUseCase) RemoveClient(client chan models.StreamEvent) {
 	sm.distributor.RemoveClient(client) // Delegate to the actual StreamDistributor
+
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	// Clients may be removed more than once (e.g., on disconnect and on handler exit)
+	if _, ok := sm.clients[client]; !ok {
+		return
+	}
+	delete(sm.clients, client)
+	if len(sm.clients) == 0 && sm.running {
+		sm.scheduleIdleStop()
+	}
 }
 
 // Broadcast delegates to the underlying StreamDistributor
-func (sm *StreamManagerUseCase) Broadcast(data string) {
-	sm.distributor.Broadcast(data) // Delegate to the actual StreamDistributor
+func (sm *StreamManagerUseCase) Broadcast(event models.StreamEvent) {
+	sm.distributor.Broadcast(event) // Delegate to the actual StreamDistributor
 }
 
 // Stop delegates to the underlying StreamDistributor
 func (sm *StreamManagerUseCase) Stop() {
 	sm.distributor.Stop() // Delegate to the actual StreamDistributor
 }
+
+// Vehicle returns a current vehicle from the underlying StreamStore, and false if it is unknown
+// or the upstream stream is not running
+func (sm *StreamManagerUseCase) Vehicle(id string) (models.Vehicle, bool) {
+	if !sm.isRunning() {
+		return models.Vehicle{}, false
+	}
+	return sm.store.Vehicle(id)
+}
+
+// Vehicles returns the current vehicles from the underlying StreamStore, and false unless the
+// upstream stream is running and has been loaded
+func (sm *StreamManagerUseCase) Vehicles() ([]models.Vehicle, bool) {
+	if !sm.isRunning() {
+		return nil, false
+	}
+	return sm.store.Vehicles()
+}
+
+// isRunning reports whether the upstream stream is running.
+func (sm *StreamManagerUseCase) isRunning() bool {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	return sm.running
+}
+
+// Snapshot returns a reset event with the current resources from the underlying StreamStore
+func (sm *StreamManagerUseCase) Snapshot() models.StreamEvent {
+	return sm.store.Snapshot()
+}
@@ -0,0 +1,132 @@
+package usecases
+
+import (
+	"context"
+	"explorer/internal/adapters/distribute"
+	"explorer/internal/adapters/store"
+	"explorer/internal/core/domain/models"
+	ports "explorer/internal/ports/streaming"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowSource is a stream source that only stops once release is closed, counting its starts
+type slowSource struct {
+	idleSource
+	release chan struct{}
+	starts  *atomic.Int32
+}
+
+func (s slowSource) Start(ctx context.Context, url, apiKey string) <-chan struct{} {
+	s.starts.Add(1)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		<-s.release
+	}()
+	return stopped
+}
+
+// lateSource is a stream source that applies one last event to its store after being cancelled,
+// like a source caught decoding an event when the stream stops
+type lateSource struct {
+	idleSource
+	store ports.StreamStore
+}
+
+func (s lateSource) Start(ctx context.Context, url, apiKey string) <-chan struct{} {
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		s.store.Apply(models.VehicleReset{Vehicles: []models.Vehicle{{ID: "R-1"}}})
+	}()
+	return stopped
+}
+
+func TestStreamManagerClearsStoreOnceSourceStopped(t *testing.T) {
+	streamStore := store.NewStreamStore()
+	sm := NewStreamManagerUseCase(lateSource{store: streamStore},
+		distribute.NewClientDistributor(distribute.DefaultDistributorOptions()), streamStore, time.Second)
+
+	sm.EnsureStreaming("vehicles", "")
+	sm.Shutdown()
+
+	if vehicles, loaded := streamStore.Vehicles(); loaded || len(vehicles) > 0 {
+		t.Errorf("store holds %d vehicles after the stream stopped, loaded %v", len(vehicles), loaded)
+	}
+	if snapshot := streamStore.Snapshot(); snapshot != nil {
+		t.Errorf("snapshot after the stream stopped = %+v, want none", snapshot)
+	}
+}
+
+func TestStreamManagerDoesNotBlockWhileSourceStops(t *testing.T) {
+	source := slowSource{release: make(chan struct{}), starts: new(atomic.Int32)}
+	streamStore := store.NewStreamStore()
+	sm := NewStreamManagerUseCase(source,
+		distribute.NewClientDistributor(distribute.DefaultDistributorOptions()), streamStore, time.Second)
+	sm.EnsureStreaming("vehicles", "")
+
+	shutdown := make(chan struct{})
+	go func() {
+		defer close(shutdown)
+		sm.Shutdown()
+	}()
+	for sm.isRunning() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The manager answers while the source is stopping
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sm.Status()
+		sm.Vehicles()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the manager blocked while the source was stopping")
+	}
+
+	// A restart waits for the previous stream to stop before starting a new one
+	restarted := make(chan struct{})
+	go func() {
+		defer close(restarted)
+		sm.EnsureStreaming("vehicles", "")
+	}()
+	select {
+	case <-restarted:
+		t.Fatal("the stream restarted before the previous one stopped")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(source.release)
+	<-shutdown
+	<-restarted
+	if starts := source.starts.Load(); starts != 2 || !sm.isRunning() {
+		t.Errorf("started %d times, running %v, want restarted once the previous stream stopped", starts, sm.isRunning())
+	}
+	sm.Shutdown()
+}
+
+func TestStreamManagerIdleStopSkipsHeldManager(t *testing.T) {
+	sm := NewStreamManagerUseCase(idleSource{},
+		distribute.NewClientDistributor(distribute.DefaultDistributorOptions()), store.NewStreamStore(), 5*time.Millisecond)
+	defer sm.Shutdown()
+	sm.EnsureStreaming("vehicles", "")
+
+	// The last client leaves while another caller holds the manager to add its client
+	client := make(chan models.StreamEvent, 1)
+	sm.AddClient(client, ports.ClientOptions{})
+	release := sm.hold()
+	sm.RemoveClient(client)
+
+	time.Sleep(50 * time.Millisecond)
+	if !sm.isRunning() {
+		t.Fatal("the stream was stopped while the manager was held")
+	}
+	release()
+}
@@ -0,0 +1,19 @@
+package usecases
+
+import (
+	"explorer/internal/constants"
+	"sort"
+)
+
+// PredictionStreamURL returns the URL of the upstream stream of predictions for the given stops
+// and/or routes on the API at baseURL. Predictions are not filtered per client, so every
+// distinct query gets its own stream, shared by every client asking for the same stops and routes.
+func PredictionStreamURL(baseURL string, stopIDs, routeIDs []string) string {
+	// Canonical order, so equal queries share a stream
+	stopIDs = append([]string{}, stopIDs...)
+	sort.Strings(stopIDs)
+	routeIDs = append([]string{}, routeIDs...)
+	sort.Strings(routeIDs)
+
+	return constants.PredictionStreamUrl(baseURL, stopIDs, routeIDs)
+}
@@ -0,0 +1,98 @@
+package usecases
+
+import (
+	"explorer/internal/core/domain/models"
+	ports "explorer/internal/ports/streaming"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StreamFactory builds the source, distributor and store of a new upstream stream
+type StreamFactory func() (ports.StreamSource, ports.StreamDistributor, ports.StreamStore)
+
+// StreamRegistryUseCase keeps one StreamManagerUseCase per distinct upstream stream URL,
+// created on demand and shared between every client asking for the same stream.
+type StreamRegistryUseCase struct {
+	factory     StreamFactory
+	gracePeriod time.Duration // Grace period applied to every stream manager
+
+	mutex    sync.Mutex
+	managers map[string]*StreamManagerUseCase // Stream managers keyed by upstream URL
+}
+
+func NewStreamRegistryUseCase(factory StreamFactory, gracePeriod time.Duration) *StreamRegistryUseCase {
+	return &StreamRegistryUseCase{
+		factory:     factory,
+		gracePeriod: gracePeriod,
+		managers:    make(map[string]*StreamManagerUseCase),
+	}
+}
+
+// Acquire returns the stream manager for the given upstream URL, creating it if needed, and
+// holds it until release is called, so it is not discarded before the caller has added its client.
+// Managers whose upstream has stopped and which are neither held nor have clients are discarded
+// whenever a new manager is created, so the registry does not grow with every query ever made.
+func (r *StreamRegistryUseCase) Acquire(url string) (ports.StreamManager, func()) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	sm, ok := r.managers[url]
+	if !ok {
+		// Discard idle managers before adding a new one
+		for key, idle := range r.managers {
+			if idle.Idle() {
+				delete(r.managers, key)
+			}
+		}
+
+		log.Printf("Creating stream for %s", url)
+		source, distributor, store := r.factory()
+		sm = NewStreamManagerUseCase(source, distributor, store, r.gracePeriod)
+		sm.url = url
+		r.managers[url] = sm
+	}
+
+	// Hold the manager while the registry is locked, so it cannot be found idle in between
+	return sm, sm.hold()
+}
+
+// Lookup returns the stream manager for the given upstream URL, and false if there is none.
+// Unlike Acquire, it never creates one.
+func (r *StreamRegistryUseCase) Lookup(url string) (ports.StreamManager, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	sm, ok := r.managers[url]
+	return sm, ok
+}
+
+// Statuses reports the status of every stream in the registry, ordered by URL.
+func (r *StreamRegistryUseCase) Statuses() []models.StreamStatus {
+	r.mutex.Lock()
+	managers := make([]*StreamManagerUseCase, 0, len(r.managers))
+	for _, sm := range r.managers {
+		managers = append(managers, sm)
+	}
+	r.mutex.Unlock()
+
+	statuses := make([]models.StreamStatus, 0, len(managers))
+	for _, sm := range managers {
+		statuses = append(statuses, sm.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].URL < statuses[j].URL
+	})
+	return statuses
+}
+
+// Shutdown stops every upstream stream immediately.
+func (r *StreamRegistryUseCase) Shutdown() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, sm := range r.managers {
+		sm.Shutdown()
+	}
+}
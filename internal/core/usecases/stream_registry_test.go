@@ -0,0 +1,77 @@
+package usecases
+
+import (
+	"context"
+	"explorer/internal/adapters/distribute"
+	"explorer/internal/adapters/store"
+	"explorer/internal/core/domain/models"
+	ports "explorer/internal/ports/streaming"
+	"testing"
+	"time"
+)
+
+// idleSource is a stream source that never connects, standing in for the MBTA API
+type idleSource struct{}
+
+func (idleSource) Start(ctx context.Context, url, apiKey string) <-chan struct{} {
+	stopped := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		close(stopped)
+	}()
+	return stopped
+}
+func (idleSource) State() ports.ConnectionState          { return ports.StateStopped }
+func (idleSource) SourceStats() models.StreamSourceStats { return models.StreamSourceStats{} }
+
+func newTestRegistry() *StreamRegistryUseCase {
+	return NewStreamRegistryUseCase(func() (ports.StreamSource, ports.StreamDistributor, ports.StreamStore) {
+		return idleSource{}, distribute.NewClientDistributor(distribute.DefaultDistributorOptions()), store.NewStreamStore()
+	}, time.Second)
+}
+
+func TestStreamRegistryKeepsHeldManagers(t *testing.T) {
+	registry := newTestRegistry()
+
+	// A manager acquired but without a client yet survives the creation of another manager
+	held, release := registry.Acquire("a")
+	_, releaseB := registry.Acquire("b")
+	releaseB()
+	if sm, ok := registry.Lookup("a"); !ok || sm != held {
+		t.Fatal("held manager was discarded when another manager was created")
+	}
+
+	// Acquiring the same URL again shares the manager
+	again, releaseAgain := registry.Acquire("a")
+	if again != held {
+		t.Error("Acquire returned a different manager for the same URL")
+	}
+	releaseAgain()
+
+	// Once released without clients, it is discarded when the next manager is created
+	release()
+	release() // Releasing twice has no further effect
+	_, releaseC := registry.Acquire("c")
+	defer releaseC()
+	if _, ok := registry.Lookup("a"); ok {
+		t.Error("idle manager was kept after being released")
+	}
+	if _, ok := registry.Lookup("b"); ok {
+		t.Error("idle manager was kept after being released")
+	}
+}
+
+func TestStreamRegistryKeepsManagersWithClients(t *testing.T) {
+	registry := newTestRegistry()
+
+	sm, release := registry.Acquire("a")
+	client := make(chan models.StreamEvent, 1)
+	sm.AddClient(client, ports.ClientOptions{})
+	release()
+
+	_, releaseB := registry.Acquire("b")
+	defer releaseB()
+	if _, ok := registry.Lookup("a"); !ok {
+		t.Error("manager with a client was discarded")
+	}
+}
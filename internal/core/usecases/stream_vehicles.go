@@ -1,36 +1,119 @@
 package usecases
 
 import (
-	"context"
+	"explorer/internal/constants"
+	"explorer/internal/core/domain/models"
 	ports "explorer/internal/ports/streaming"
+	"fmt"
+	"slices"
+	"sort"
 )
 
+// StreamVehiclesUseCase attaches clients to a vehicle stream, whose filter can be changed while connected
 type StreamVehiclesUseCase struct {
-	streamManager ports.StreamManager
+	*StreamUseCase
 }
 
 func NewStreamVehiclesUseCase(sm ports.StreamManager) *StreamVehiclesUseCase {
 	return &StreamVehiclesUseCase{
-		streamManager: sm,
+		StreamUseCase: NewStreamUseCase(sm),
 	}
 }
 
-// StreamSetup initializes the stream and returns a client channel
-func (uc *StreamVehiclesUseCase) StreamSetup(url, apiKey string) chan string {
-	// Ensure the stream is running
-	uc.streamManager.EnsureStreaming(url, apiKey)
+// VehicleStream identifies an upstream vehicle stream, selecting vehicles either by route
+// or, when RouteIDs is nil, by route type.
+type VehicleStream struct {
+	RouteIDs   []string // Routes carried by the stream
+	RouteTypes []int    // Route types carried by the stream
+}
+
+// SubwayVehicleStream is the stream shared by every subway client. It carries every light rail
+// and subway route and is filtered per client.
+var SubwayVehicleStream = VehicleStream{
+	RouteIDs:   constants.SubwayRouteIDs,
+	RouteTypes: []int{models.RouteTypeLightRail, models.RouteTypeSubway},
+}
+
+// VehicleStreamFor returns the upstream stream able to serve the given filter. Subway routes
+// share a single stream and are filtered per client; any other route set or route type set
+// gets its own stream, shared by every client asking for the same routes or route types.
+func VehicleStreamFor(filter models.VehicleFilter) VehicleStream {
+	if (filter.RouteIDs == nil && filter.RouteTypes == nil) || SubwayVehicleStream.Serves(filter) {
+		return SubwayVehicleStream
+	}
+
+	// Prefer the narrower route stream, the route types are then filtered per client
+	if filter.RouteIDs != nil {
+		routeIDs := append([]string{}, filter.RouteIDs...)
+		sort.Strings(routeIDs) // Canonical order, so equal route sets share a stream
+		return VehicleStream{RouteIDs: routeIDs}
+	}
+
+	routeTypes := append([]int{}, filter.RouteTypes...)
+	sort.Ints(routeTypes) // Canonical order, so equal route type sets share a stream
+	return VehicleStream{RouteTypes: routeTypes}
+}
+
+// URL returns the URL of the stream on the API at baseURL
+func (s VehicleStream) URL(baseURL string) string {
+	if s.RouteIDs != nil {
+		return constants.VehicleStreamUrl(baseURL, s.RouteIDs)
+	}
+	return constants.VehicleStreamByRouteTypeUrl(baseURL, s.RouteTypes)
+}
+
+// Serves reports whether the stream carries every vehicle matched by the filter, i.e. every
+// route in filter.RouteIDs or every route type in filter.RouteTypes.
+func (s VehicleStream) Serves(filter models.VehicleFilter) bool {
+	// The stream serves the filter unless one of the requested routes (or route types) is not carried
+	if filter.RouteIDs != nil && s.RouteIDs != nil &&
+		!slices.ContainsFunc(filter.RouteIDs, func(id string) bool { return !slices.Contains(s.RouteIDs, id) }) {
+		return true
+	}
+	return filter.RouteTypes != nil && s.RouteTypes != nil &&
+		!slices.ContainsFunc(filter.RouteTypes, func(t int) bool { return !slices.Contains(s.RouteTypes, t) })
+}
 
-	// Create and register client channel
-	clientChan := make(chan string, 100)
-	uc.streamManager.AddClient(clientChan)
+// String describes the vehicles carried by the stream, e.g. "routes [Red Orange]"
+func (s VehicleStream) String() string {
+	if s.RouteIDs != nil {
+		return fmt.Sprintf("routes %v", s.RouteIDs)
+	}
+	return fmt.Sprintf("route types %v", s.RouteTypes)
+}
 
-	return clientChan
+// CurrentVehicles returns the vehicles matching the filter from the store of the running upstream
+// stream serving it, without a round trip to the MBTA API. It returns false if there is no such
+// stream, e.g. for an empty filter since no stream carries every vehicle, or it has not been loaded yet.
+func CurrentVehicles(registry ports.StreamRegistry, baseURL string, filter models.VehicleFilter) ([]models.Vehicle, bool) {
+	stream := VehicleStreamFor(filter)
+	if !stream.Serves(filter) {
+		return nil, false
+	}
+	streamManager, ok := registry.Lookup(stream.URL(baseURL))
+	if !ok {
+		return nil, false
+	}
+	vehicles, ok := streamManager.Vehicles()
+	if !ok {
+		return nil, false
+	}
+
+	// The stream may carry more routes than requested, e.g. the shared subway stream
+	matching := make([]models.Vehicle, 0, len(vehicles))
+	for _, vehicle := range vehicles {
+		if filter.Matches(vehicle) {
+			matching = append(matching, vehicle)
+		}
+	}
+	return matching, true
 }
 
-// HandleDisconnect sets up disconnection handling for a client
-func (uc *StreamVehiclesUseCase) HandleDisconnect(ctx context.Context, clientChan chan string) {
-	go func() {
-		<-ctx.Done()
-		uc.streamManager.RemoveClient(clientChan)
-	}()
+// UpdateFilter replaces the filter of a connected client and resends the matching
+// vehicle set so the client starts from a consistent state
+func (uc *StreamVehiclesUseCase) UpdateFilter(clientChan chan models.StreamEvent, filter models.VehicleFilter) {
+	uc.streamManager.UpdateClient(clientChan, ports.ClientOptions{
+		Filter:   filter,
+		Snapshot: uc.snapshotEvent,
+	})
 }
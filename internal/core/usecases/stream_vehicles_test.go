@@ -0,0 +1,33 @@
+package usecases
+
+import (
+	"explorer/internal/core/domain/models"
+	"testing"
+)
+
+func TestVehicleStreamServes(t *testing.T) {
+	routes := VehicleStream{RouteIDs: []string{"1", "39"}}
+	busTypes := VehicleStream{RouteTypes: []int{models.RouteTypeBus}}
+
+	tests := []struct {
+		name   string
+		stream VehicleStream
+		filter models.VehicleFilter
+		want   bool
+	}{
+		{name: "every route carried", stream: routes, filter: models.VehicleFilter{RouteIDs: []string{"39", "1"}}, want: true},
+		{name: "some routes carried", stream: routes, filter: models.VehicleFilter{RouteIDs: []string{"1"}}, want: true},
+		{name: "route missing", stream: routes, filter: models.VehicleFilter{RouteIDs: []string{"1", "66"}}, want: false},
+		{name: "route types of a route stream", stream: routes, filter: models.VehicleFilter{RouteTypes: []int{models.RouteTypeBus}}, want: false},
+		{name: "every route type carried", stream: busTypes, filter: models.VehicleFilter{RouteTypes: []int{models.RouteTypeBus}}, want: true},
+		{name: "route type missing", stream: busTypes, filter: models.VehicleFilter{RouteTypes: []int{models.RouteTypeBus, models.RouteTypeFerry}}, want: false},
+		{name: "subway routes", stream: SubwayVehicleStream, filter: models.VehicleFilter{RouteIDs: []string{"Red", "Mattapan"}}, want: true},
+		{name: "empty filter", stream: SubwayVehicleStream, filter: models.VehicleFilter{}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.stream.Serves(tt.filter); got != tt.want {
+			t.Errorf("%s: %v.Serves(%+v) = %v, want %v", tt.name, tt.stream, tt.filter, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"explorer/internal/core/domain/models"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// GetTrip assembles the detail of a trip from several MBTA API calls. The trip is fetched first, then its
+// schedules, predictions, vehicle and shape concurrently. Only the trip is required: anything else that
+// cannot be fetched is logged and left out, e.g. a trip added to the schedule has no scheduled times.
+func (f *MbtaApiHelperImpl) GetTrip(ctx context.Context, tripID string) (models.TripDetail, error) {
+	trip, err := f.client.FetchTrip(ctx, tripID)
+	if err != nil {
+		return models.TripDetail{}, err
+	}
+	detail := models.TripDetail{Trip: trip}
+
+	var (
+		wg          sync.WaitGroup
+		schedules   []models.Schedule
+		predictions []models.Prediction
+	)
+	wg.Add(4)
+
+	// Scheduled times, not limited to today's service date since the trip may run on another date
+	go func() {
+		defer wg.Done()
+		var err error
+		if schedules, err = f.GetSchedules(ctx, models.ScheduleFilter{TripID: tripID}); err != nil {
+			log.Println("Failed to fetch trip schedules:", err)
+		}
+	}()
+
+	// Predicted times, never cached
+	go func() {
+		defer wg.Done()
+		var err error
+		if predictions, err = f.client.FetchTripPredictions(ctx, tripID); err != nil {
+			log.Println("Failed to fetch trip predictions:", err)
+		}
+	}()
+
+	// Current position of the vehicle serving the trip, if one is assigned
+	go func() {
+		defer wg.Done()
+		vehicle, err := f.client.FetchTripVehicle(ctx, tripID)
+		if err != nil {
+			log.Println("Failed to fetch trip vehicle:", err)
+			return
+		}
+		if vehicle != nil {
+			if routeType, ok := f.RouteType(ctx, vehicle.Route); ok {
+				vehicle.SetRouteType(routeType)
+			}
+		}
+		detail.Vehicle = vehicle
+	}()
+
+	// Shape followed by the trip
+	go func() {
+		defer wg.Done()
+		if trip.ShapeID == "" {
+			return
+		}
+		shape, err := f.getShape(ctx, trip.ShapeID)
+		if err != nil {
+			log.Println("Failed to fetch trip shape:", err)
+			return
+		}
+		detail.Shape = shape
+	}()
+
+	wg.Wait()
+
+	// Everything left out may have been given up on, rather than missing
+	if err := ctx.Err(); err != nil {
+		return models.TripDetail{}, err
+	}
+	detail.Stops = f.tripStops(ctx, schedules, predictions)
+	return detail, nil
+}
+
+// tripStops merges the schedules and predictions of a trip into its list of stops, ordered by stop sequence.
+// Stops skipped or added by the predictions keep their scheduled times, if any, alongside the prediction.
+func (f *MbtaApiHelperImpl) tripStops(ctx context.Context, schedules []models.Schedule, predictions []models.Prediction) []models.TripStop {
+	stops := []models.TripStop{}
+	bySequence := make(map[int]int) // Index in stops by stop sequence
+
+	for _, schedule := range schedules {
+		bySequence[schedule.StopSequence] = len(stops)
+		stops = append(stops, models.TripStop{
+			StopID:             schedule.StopID,
+			StopSequence:       schedule.StopSequence,
+			ScheduledArrival:   schedule.ArrivalTime,
+			ScheduledDeparture: schedule.DepartureTime,
+		})
+	}
+
+	for _, prediction := range predictions {
+		i, ok := bySequence[prediction.StopSequence]
+		if !ok {
+			// A stop without a schedule, e.g. on an added trip
+			i = len(stops)
+			bySequence[prediction.StopSequence] = i
+			stops = append(stops, models.TripStop{StopID: prediction.StopID, StopSequence: prediction.StopSequence})
+		}
+		stops[i].PredictedArrival = prediction.ArrivalTime
+		stops[i].PredictedDeparture = prediction.DepartureTime
+		stops[i].Status = prediction.Status
+		stops[i].ScheduleRelationship = prediction.ScheduleRelationship
+	}
+
+	sort.Slice(stops, func(i, j int) bool {
+		return stops[i].StopSequence < stops[j].StopSequence
+	})
+
+	// Name the stops, looked up together and cached
+	stopIDs := make([]string, len(stops))
+	for i, stop := range stops {
+		stopIDs[i] = stop.StopID
+	}
+	names := f.stopNames(ctx, stopIDs)
+	for i := range stops {
+		stops[i].StopName = names[stops[i].StopID]
+	}
+
+	return stops
+}
+
+// getShape retrieves a single shape as latitude/longitude pairs with caching
+func (f *MbtaApiHelperImpl) getShape(ctx context.Context, shapeID string) ([][]float64, error) {
+	cacheKey := "shape:" + shapeID
+	item, err := f.cacheGet(ctx, cacheKey)
+	if err == nil {
+		log.Println("Cache hit for getShape:", shapeID)
+		var shape [][]float64
+		if err := json.Unmarshal(item.Value, &shape); err == nil {
+			return shape, nil
+		}
+		log.Println("Failed to unmarshal cached data, fetching fresh data.")
+	}
+
+	// Cache miss or unmarshalling failure
+	shape, err := f.client.FetchShape(ctx, shapeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the result
+	value, _ := json.Marshal(shape)
+	if err := f.cacheSet(ctx, &memcache.Item{Key: cacheKey, Value: value}); err != nil {
+		log.Println("Failed to cache data for getShape:", err)
+	}
+
+	return shape, nil
+}
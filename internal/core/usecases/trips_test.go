@@ -0,0 +1,118 @@
+package usecases
+
+import (
+	"context"
+	"explorer/internal/core/domain/models"
+	"explorer/internal/ports/data"
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// tripClient serves the schedules and stops of a trip, recording the schedule filter it is asked for
+type tripClient struct {
+	data.MBTAClient // Other calls are not used
+	filter          models.ScheduleFilter
+}
+
+func (c *tripClient) FetchSchedules(ctx context.Context, filter models.ScheduleFilter) ([]models.Schedule, error) {
+	c.filter = filter
+	return nil, nil
+}
+
+func (c *tripClient) FetchStopsByID(ctx context.Context, stopIDs []string) ([]models.Stop, error) {
+	names := map[string]string{"70061": "Alewife", "70063": "Davis", "70065": "Porter", "70067": "Harvard"}
+	var stops []models.Stop
+	for _, stopID := range stopIDs {
+		if name, ok := names[stopID]; ok {
+			stops = append(stops, models.Stop{ID: stopID, Attributes: models.StopAttributes{Name: name}})
+		}
+	}
+	return stops, nil
+}
+
+// newTripHelper returns a helper fetching from a trip client, with no cache reachable
+func newTripHelper() (*MbtaApiHelperImpl, *tripClient) {
+	client := &tripClient{}
+	return &MbtaApiHelperImpl{client: client, cache: memcache.New("127.0.0.1:1")}, client
+}
+
+func TestGetSchedulesOfTripIsNotLimitedToToday(t *testing.T) {
+	helper, client := newTripHelper()
+
+	if _, err := helper.GetSchedules(context.Background(), models.ScheduleFilter{TripID: "67268866"}); err != nil {
+		t.Fatal(err)
+	}
+	if client.filter.Date != "" {
+		t.Errorf("trip schedules fetched for %q, want no service date", client.filter.Date)
+	}
+
+	if _, err := helper.GetSchedules(context.Background(), models.ScheduleFilter{RouteID: "Red"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := serviceDate(time.Now()); client.filter.Date != want {
+		t.Errorf("route schedules fetched for %q, want today's service date %q", client.filter.Date, want)
+	}
+}
+
+func TestTripStopsMergesSchedulesAndPredictions(t *testing.T) {
+	helper, _ := newTripHelper()
+	at := func(minute int) *time.Time {
+		t := time.Date(2025, 1, 12, 8, minute, 0, 0, time.UTC)
+		return &t
+	}
+	boarding, skipped, added := "Boarding", "SKIPPED", "ADDED"
+
+	schedules := []models.Schedule{
+		{StopID: "70063", StopSequence: 20, ArrivalTime: at(3), DepartureTime: at(4)},
+		{StopID: "70061", StopSequence: 10, DepartureTime: at(0)},
+		{StopID: "70065", StopSequence: 30, ArrivalTime: at(6), DepartureTime: at(7)},
+	}
+	predictions := []models.Prediction{
+		{StopID: "70063", StopSequence: 20, ArrivalTime: at(5), DepartureTime: at(6), Status: &boarding},
+		{StopID: "70065", StopSequence: 30, ScheduleRelationship: &skipped},
+		{StopID: "70067", StopSequence: 40, ArrivalTime: at(12), ScheduleRelationship: &added},
+	}
+
+	stops := helper.tripStops(context.Background(), schedules, predictions)
+
+	want := []struct {
+		stopID, name         string
+		scheduled, predicted *time.Time
+		relationship         *string
+	}{
+		{stopID: "70061", name: "Alewife"},                                          // Departed, no longer predicted
+		{stopID: "70063", name: "Davis", scheduled: at(3), predicted: at(5)},        // Scheduled and predicted
+		{stopID: "70065", name: "Porter", scheduled: at(6), relationship: &skipped}, // Skipped, keeping its scheduled time
+		{stopID: "70067", name: "Harvard", predicted: at(12), relationship: &added}, // Added by the predictions only
+	}
+	if len(stops) != len(want) {
+		t.Fatalf("got %d stops, want %d", len(stops), len(want))
+	}
+	for i, w := range want {
+		stop := stops[i]
+		if stop.StopID != w.stopID || stop.StopName != w.name {
+			t.Errorf("stop %d = %s %q, want %s %q", i, stop.StopID, stop.StopName, w.stopID, w.name)
+		}
+		if !sameTime(stop.ScheduledArrival, w.scheduled) || !sameTime(stop.PredictedArrival, w.predicted) {
+			t.Errorf("stop %s arrivals = %v scheduled, %v predicted, want %v and %v",
+				stop.StopID, stop.ScheduledArrival, stop.PredictedArrival, w.scheduled, w.predicted)
+		}
+		if (stop.ScheduleRelationship == nil) != (w.relationship == nil) ||
+			(w.relationship != nil && *stop.ScheduleRelationship != *w.relationship) {
+			t.Errorf("stop %s schedule relationship = %v, want %v", stop.StopID, stop.ScheduleRelationship, w.relationship)
+		}
+	}
+	if stops[1].Status == nil || *stops[1].Status != boarding || !sameTime(stops[1].ScheduledDeparture, at(4)) {
+		t.Errorf("stop 70063 = %+v, want its scheduled departure and the Boarding status", stops[1])
+	}
+}
+
+// sameTime reports whether two optional times are both nil or equal
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
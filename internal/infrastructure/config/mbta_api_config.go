@@ -1,7 +1,20 @@
 package config
 
-import "os"
+import (
+	"explorer/internal/constants"
+	"os"
+	"strings"
+)
 
 func GetAPIKey() string {
 	return os.Getenv("MBTA_API_KEY")
 }
+
+// GetAPIBaseURL returns the base URL of the MBTA V3 API, which can point at a local
+// fake server for development and testing. It defaults to the production API.
+func GetAPIBaseURL() string {
+	if baseURL := os.Getenv("MBTA_API_BASE_URL"); baseURL != "" {
+		return strings.TrimSuffix(baseURL, "/")
+	}
+	return constants.MbtaApiBaseUrl
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// GetSlowConsumerPolicy returns the policy applied to stream clients that cannot keep up
+// ("drop-newest", "drop-oldest", "disconnect" or "coalesce"), or an empty string for the default.
+func GetSlowConsumerPolicy() string {
+	return os.Getenv("STREAM_SLOW_CONSUMER_POLICY")
+}
+
+// GetSlowConsumerMaxDrops returns the number of consecutive dropped events after which
+// the "disconnect" policy disconnects a client, or 0 if it is not configured.
+func GetSlowConsumerMaxDrops() int {
+	maxDrops, err := strconv.Atoi(os.Getenv("STREAM_SLOW_CONSUMER_MAX_DROPS"))
+	if err != nil {
+		return 0
+	}
+	return maxDrops
+}
+
+// GetStreamGracePeriod returns how long the upstream stream is kept open after the last
+// client disconnects (e.g., "90s" or "5m"). It defaults to one minute.
+func GetStreamGracePeriod() time.Duration {
+	gracePeriod, err := time.ParseDuration(os.Getenv("STREAM_GRACE_PERIOD"))
+	if err != nil || gracePeriod < 0 {
+		return time.Minute
+	}
+	return gracePeriod
+}
+
+// GetStreamIdleTimeout returns how long the upstream stream may go without receiving any
+// bytes before it reconnects (e.g., "45s"), or 0 if it is not configured.
+func GetStreamIdleTimeout() time.Duration {
+	idleTimeout, err := time.ParseDuration(os.Getenv("STREAM_IDLE_TIMEOUT"))
+	if err != nil || idleTimeout < 0 {
+		return 0
+	}
+	return idleTimeout
+}
+
+// GetStreamRecordFile returns the file every raw upstream stream event is recorded to,
+// or an empty string if recording is disabled.
+func GetStreamRecordFile() string {
+	return os.Getenv("STREAM_RECORD_FILE")
+}
+
+// GetStreamReplayFile returns the recording to play back instead of connecting to the
+// MBTA API, or an empty string to stream live data.
+func GetStreamReplayFile() string {
+	return os.Getenv("STREAM_REPLAY_FILE")
+}
+
+// GetStreamReplaySpeed returns the playback speed of the recording, either a factor
+// (e.g., "1" for real time, "10" for ten times faster) or a fixed step between events (e.g., "500ms").
+func GetStreamReplaySpeed() string {
+	return os.Getenv("STREAM_REPLAY_SPEED")
+}
+
+// GetStreamReplayLoop returns whether the recording starts over when it ends.
+func GetStreamReplayLoop() bool {
+	loop, _ := strconv.ParseBool(os.Getenv("STREAM_REPLAY_LOOP"))
+	return loop
+}
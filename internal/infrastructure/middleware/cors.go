@@ -7,12 +7,30 @@ import (
 	"github.com/rs/cors"
 )
 
+// AllowedOrigins lists the frontend origins permitted to call the API
+var AllowedOrigins = []string{"http://localhost:5173"}
+
 // CORS Middleware Configuration
 func SetCorsHandler(router *mux.Router) http.Handler {
 	return cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:5173"},
+		AllowedOrigins:   AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
 		AllowedHeaders:   []string{"Content-Type", "Authorization"},
 		AllowCredentials: true,
 	}).Handler(router)
 }
+
+// IsAllowedOrigin reports whether a request may be served based on its Origin header.
+// Requests without an Origin header (e.g., native or command-line clients) are allowed.
+func IsAllowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range AllowedOrigins {
+		if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
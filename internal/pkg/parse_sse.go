@@ -0,0 +1,47 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SSEEvent holds the fields of a single Server-Sent Events message
+type SSEEvent struct {
+	ID    string // Value of the "id" field, empty if not present
+	Event string // Value of the "event" field
+	Data  string // Data lines joined with newline characters
+}
+
+// ParseSSE extracts the "id", "event" and "data" fields from a raw Server-Sent Events message.
+// Multiple data lines are joined with newline characters, as described by the SSE specification.
+func ParseSSE(raw string) SSEEvent {
+	var event SSEEvent
+	var dataLines []string // Accumulates "data" field values
+
+	// Process each line to extract relevant SSE fields
+	for _, line := range strings.Split(raw, "\n") {
+		if strings.HasPrefix(line, "id:") {
+			// Extract and trim the value of the "id" field
+			event.ID = strings.TrimSpace(line[len("id:"):])
+		} else if strings.HasPrefix(line, "event:") {
+			// Extract and trim the value of the "event" field
+			event.Event = strings.TrimSpace(line[len("event:"):])
+		} else if strings.HasPrefix(line, "data:") {
+			// Extract and trim the value of the "data" field
+			dataLines = append(dataLines, strings.TrimSpace(line[len("data:"):]))
+		}
+	}
+
+	event.Data = strings.Join(dataLines, "\n")
+	return event
+}
+
+// FormatSSE formats an event as an SSE-compliant message. The "id" field is omitted when empty,
+// and data spanning several lines is sent as one "data" field per line.
+func FormatSSE(event SSEEvent) string {
+	data := strings.ReplaceAll(event.Data, "\n", "\ndata: ")
+	if event.ID == "" {
+		return fmt.Sprintf("event: %s\ndata: %s\n\n", event.Event, data)
+	}
+	return fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Event, data)
+}
@@ -1,10 +1,37 @@
 package data
 
-import "explorer/internal/core/domain/models"
+import (
+	"context"
+	"errors"
+	"explorer/internal/core/domain/models"
+)
 
-// MBTAClient is an interface that defines methods for fetching stops, shapes, and live vehicle data from the MBTA API
+// ErrNotFound is wrapped by the errors of MBTAClient methods when the requested resource does not exist
+var ErrNotFound = errors.New("not found")
+
+// MBTAClient is an interface that defines methods for fetching stops, shapes, routes, live vehicle data, predictions, schedules and alerts from the MBTA API
+// Every request is cancelled when its context is done, failing with the context's error
 type MBTAClient interface {
-	FetchStops(routeID string) ([]models.Stop, error)             // Method to fetch stops for a given route
-	FetchShapes(routeID string) (models.DecodedRouteShape, error) // Method to fetch shapes for a given route
-	FetchLiveData(routeID string) ([]models.Vehicle, error)       // Method to fetch live vehicle data for a given route
+	FetchStops(ctx context.Context, routeID string) ([]models.Stop, error)                         // Method to fetch stops for a given route
+	FetchShapes(ctx context.Context, routeID string) (models.DecodedRouteShape, error)             // Method to fetch shapes for a given route
+	FetchRoutes(ctx context.Context, routeTypes []int) ([]models.Route, error)                     // Method to fetch routes, optionally of the given route types only
+	FetchLiveData(ctx context.Context, routeID string, routeTypes []int) ([]models.Vehicle, error) // Method to fetch live vehicle data for the given routes and/or route types
+	FetchPredictions(ctx context.Context, stopID, routeID string) ([]models.Prediction, error)     // Method to fetch predictions for the given stops and/or routes
+	FetchTrip(ctx context.Context, tripID string) (models.Trip, error)                             // Method to fetch a trip, failing with ErrNotFound if it does not exist
+	FetchTripPredictions(ctx context.Context, tripID string) ([]models.Prediction, error)          // Method to fetch the predictions of a trip
+	FetchTripVehicle(ctx context.Context, tripID string) (*models.Vehicle, error)                  // Method to fetch the vehicle serving a trip, nil if none is assigned
+	FetchShape(ctx context.Context, shapeID string) ([][]float64, error)                           // Method to fetch a single shape as latitude/longitude pairs
+	FetchSchedules(ctx context.Context, filter models.ScheduleFilter) ([]models.Schedule, error)   // Method to fetch the schedules of a service date matching the filter
+	FetchStopsByID(ctx context.Context, stopIDs []string) ([]models.Stop, error)                   // Method to fetch the given stops
+	FetchAlerts(ctx context.Context, filter models.AlertFilter) ([]models.Alert, error)            // Method to fetch the alerts in effect matching the filter
+}
+
+// RouteTypeResolver looks up the route type (mode) of a route by its ID
+type RouteTypeResolver interface {
+	RouteType(ctx context.Context, routeID string) (int, bool) // The route type, and false if the route is unknown
+}
+
+// AlertResolver fills in the routes, stops and modes affected by alerts from their informed entities
+type AlertResolver interface {
+	ResolveAlerts(ctx context.Context, alerts []models.Alert) // Resolve the alerts in place, leaving names empty when they cannot be looked up
 }
@@ -1,24 +1,84 @@
 // ports/streaming.go
 package ports
 
-import "context"
+import (
+	"context"
+	"explorer/internal/core/domain/models"
+	"time"
+)
+
+// ConnectionState describes the state of the connection to an external streaming data source
+type ConnectionState string
+
+const (
+	StateStopped      ConnectionState = "stopped"      // Not started, or stopped by cancellation
+	StateConnecting   ConnectionState = "connecting"   // Opening the connection
+	StateConnected    ConnectionState = "connected"    // Receiving data
+	StateReconnecting ConnectionState = "reconnecting" // Waiting to retry after an error or disconnection
+	StateFailed       ConnectionState = "failed"       // Gave up, e.g. after an authentication failure
+)
 
 // StreamSource defines how to interact with an external streaming data source
 type StreamSource interface {
-	Start(ctx context.Context, url, apiKey string)
+	// Start reading the stream in the background. The returned channel is closed once the source
+	// stops reading, e.g. after ctx is cancelled, and no longer applies or broadcasts events.
+	Start(ctx context.Context, url, apiKey string) (stopped <-chan struct{})
+	State() ConnectionState                // Current state of the connection
+	SourceStats() models.StreamSourceStats // Counters collected while reading the stream
 }
 
 // StreamDistributor defines how to manage client connections and data distribution
 type StreamDistributor interface {
-	AddClient(client chan string)
-	RemoveClient(client chan string)
-	Broadcast(data string)
+	AddClient(client chan models.StreamEvent, opts ClientOptions)
+	UpdateClient(client chan models.StreamEvent, opts ClientOptions) // Replace the options of a connected client
+	RemoveClient(client chan models.StreamEvent)
+	Broadcast(event models.StreamEvent)
 	Stop()
+	DistributorStats() models.StreamDistributorStats // Counters collected while distributing events
+}
+
+// ClientOptions describes how the distributor should treat a single client
+type ClientOptions struct {
+	Filter      models.VehicleFilter      // Only send events for vehicles matching this filter
+	Snapshot    func() models.StreamEvent // Optional initial event sent before any live data, nil for none
+	LastEventID uint64                    // Replay events after this ID instead of the snapshot, 0 for none
+
+	// Hold events and send only the latest per vehicle once per interval, 0 to send them immediately.
+	// It is only read when the client is added.
+	FlushInterval time.Duration
 }
 
 // StreamManager combines both source and distribution capabilities
 type StreamManager interface {
 	StreamSource
 	StreamDistributor
+	VehicleReader // Current vehicles, only while the upstream stream is running
 	EnsureStreaming(url, apiKey string)
+	Snapshot() models.StreamEvent // Reset event with the current resources built from the stream, nil until the first upstream reset
+	Status() models.StreamStatus
+}
+
+// StreamRegistry provides the shared StreamManager of each distinct upstream stream
+type StreamRegistry interface {
+	// Get or create the manager for the given upstream URL, held until release is called so it is
+	// not discarded as idle before the caller has added its client
+	Acquire(url string) (manager StreamManager, release func())
+	Lookup(url string) (StreamManager, bool) // Get the manager for the given upstream URL, false if there is none
+	Statuses() []models.StreamStatus         // Status of every known stream
+}
+
+// VehicleReader defines how to query the current vehicles built from stream events, so the rest
+// of the API can read current positions without another round trip to the MBTA API
+type VehicleReader interface {
+	Vehicle(id string) (models.Vehicle, bool) // The vehicle with the given ID, false if it is not known
+	Vehicles() ([]models.Vehicle, bool)       // Every vehicle ordered by ID, false until the first vehicle reset
+}
+
+// StreamStore defines how to hold the current state of the resources (e.g. vehicles, alerts or
+// predictions) built from stream events
+type StreamStore interface {
+	VehicleReader
+	Apply(event models.StreamEvent) // Apply a reset, add, update or remove event
+	Snapshot() models.StreamEvent   // Reset event with every resource currently held, possibly none, nil until the first reset
+	Clear()                         // Forget every resource, e.g. when the stream stops
 }